@@ -0,0 +1,224 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sort"
+	train "stations/pkg"
+	"strings"
+	"time"
+)
+
+// Terminal control sequences used by the animation.
+const (
+	clearScreen = "\x1b[H\x1b[2J"
+	hideCursor  = "\x1b[?25l"
+	showCursor  = "\x1b[?25h"
+)
+
+// Limits of the delay between two turns, changed with the + and - keys.
+const (
+	minDelay = 10 * time.Millisecond
+	maxDelay = 10 * time.Second
+)
+
+// animation plays a schedule turn by turn on a character grid of the network.
+type animation struct {
+	grid      *train.Grid
+	schedule  *train.Schedule
+	positions []map[string]string // Station of every train after each turn
+	delay     time.Duration
+}
+
+func newAnimation(network *train.Network, schedule *train.Schedule, width, height int, delay time.Duration) *animation {
+	return &animation{
+		grid:      train.NewGrid(network, width, height),
+		schedule:  schedule,
+		positions: schedule.Positions(),
+		delay:     delay,
+	}
+}
+
+// runAnimation plays the schedule on stdout. When standard input is a terminal the keys
+// control the animation; otherwise it plays once from the first to the last turn.
+func runAnimation(stdout io.Writer, a *animation) {
+	keys, restore := readKeys()
+	defer restore()
+
+	fmt.Fprint(stdout, hideCursor)
+	defer fmt.Fprint(stdout, showCursor)
+	a.play(stdout, keys)
+}
+
+// play draws a frame for every turn until the last one, or until q is pressed when keys is not nil.
+func (a *animation) play(w io.Writer, keys <-chan byte) {
+	turn, last := 0, len(a.positions)-1
+	paused := false
+
+	for {
+		fmt.Fprint(w, a.frame(turn, paused, keys != nil))
+
+		var tick <-chan time.Time
+		if !paused && turn < last {
+			tick = time.After(a.delay)
+		}
+		if tick == nil && keys == nil {
+			return
+		}
+
+		select {
+		case <-tick:
+			turn++
+		case key, ok := <-keys:
+			if !ok {
+				keys = nil
+				continue
+			}
+			switch key {
+			case ' ':
+				paused = !paused
+			case 'n', 'l':
+				paused = true
+				turn = min(turn+1, last)
+			case 'p', 'h':
+				paused = true
+				turn = max(turn-1, 0)
+			case 'r':
+				turn = 0
+			case '+', '=':
+				a.delay = max(a.delay/2, minDelay)
+			case '-', '_':
+				a.delay = min(a.delay*2, maxDelay)
+			case 'q', 'Q':
+				return
+			}
+		}
+	}
+}
+
+// frame draws the network with the trains as they are after the given turn,
+// followed by the moves of that turn, the legend and the keys.
+func (a *animation) frame(turn int, paused, interactive bool) string {
+	grid := a.grid.Clone()
+	trainsAt := make(map[string][]string)
+	for _, name := range a.schedule.Trains {
+		station := a.positions[turn][name]
+		trainsAt[station] = append(trainsAt[station], name)
+	}
+
+	stations := make([]string, 0, len(trainsAt))
+	for station, trains := range trainsAt {
+		stations = append(stations, station)
+		if col, row, ok := grid.Position(station); ok {
+			grid.Set(col, row, occupancyRune(len(trains)))
+		}
+	}
+	sort.Strings(stations)
+
+	var b strings.Builder
+	b.WriteString(clearScreen)
+	b.WriteString(grid.String())
+	b.WriteByte('\n')
+
+	state := "playing"
+	switch {
+	case turn == len(a.positions)-1:
+		state = "finished"
+	case paused:
+		state = "paused"
+	}
+	fmt.Fprintf(&b, "Turn %d/%d  %s, %v per turn\n", turn, len(a.positions)-1, state, a.delay)
+
+	var moves []string
+	if turn > 0 {
+		for _, move := range a.schedule.Turns[turn-1] {
+			if move.From != move.To {
+				moves = append(moves, fmt.Sprintf("%s %s->%s", move.Train, move.From, move.To))
+			}
+		}
+	}
+	fmt.Fprintf(&b, "Moves:  %s\n", strings.Join(moves, ", "))
+	for _, station := range stations {
+		fmt.Fprintf(&b, "  %s: %s\n", station, strings.Join(trainsAt[station], " "))
+	}
+
+	b.WriteString("\nLegend: o station, 1-9 trains at a station (+ for more), . track\n")
+	if interactive {
+		b.WriteString("Keys:   space pause/resume, n next turn, p previous turn, r restart, + faster, - slower, q quit\n")
+	}
+	return b.String()
+}
+
+// occupancyRune returns the character drawn on a station holding the given number of trains.
+func occupancyRune(trains int) rune {
+	if trains > 9 {
+		return '+'
+	}
+	return rune('0' + trains)
+}
+
+// readKeys switches the terminal on standard input to unbuffered input and returns the
+// keys typed from then on, together with a function that restores the terminal.
+// The channel is nil when standard input is not a terminal.
+func readKeys() (<-chan byte, func()) {
+	info, err := os.Stdin.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return nil, func() {}
+	}
+
+	saved, err := stty("-g")
+	if err != nil {
+		return nil, func() {}
+	}
+	if _, err := stty("-icanon", "-echo", "min", "1"); err != nil {
+		return nil, func() {}
+	}
+	restore := func() { stty(strings.TrimSpace(saved)) }
+
+	keys := make(chan byte)
+	go func() {
+		buf := make([]byte, 1)
+		for {
+			if n, err := os.Stdin.Read(buf); err != nil {
+				close(keys)
+				return
+			} else if n == 1 {
+				keys <- buf[0]
+			}
+		}
+	}()
+
+	// Ctrl-C quits like q, so that the terminal is restored
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	merged := make(chan byte)
+	go func() {
+		for {
+			select {
+			case key, ok := <-keys:
+				if !ok {
+					close(merged)
+					return
+				}
+				merged <- key
+			case <-interrupts:
+				merged <- 'q'
+			}
+		}
+	}()
+	return merged, func() {
+		signal.Stop(interrupts)
+		restore()
+	}
+}
+
+// stty runs stty on the terminal of standard input.
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return string(out), err
+}
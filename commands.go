@@ -0,0 +1,661 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	train "stations/pkg"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// command is a subcommand of the stations CLI.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string, stdout io.Writer) error
+}
+
+var commands = []command{
+	{"plan", "plan train movements between two stations", runPlan},
+	{"validate", "report every error and warning in a network map", runValidate},
+	{"info", "print statistics about a network map", runInfo},
+	{"render", "draw a network map", runRender},
+	{"export", "write a network map in another format", runExport},
+	{"verify", "check a schedule file against a network map", runVerify},
+	{"import", "build a network map from a GTFS feed", runImport},
+}
+
+// scheduleFormats are the output formats of the plan command.
+var scheduleFormats = map[string]func(io.Writer, *train.Schedule) error{
+	"text":     train.WriteText,
+	"json":     train.WriteJSON,
+	"timeline": train.WriteTimeline,
+}
+
+// usageError reports a malformed command line.
+type usageError struct {
+	message string
+}
+
+func (e *usageError) Error() string { return e.message }
+
+// usageFlagError wraps a flag parsing error so that it maps to the usage exit code.
+// The flag package has already printed the details and the usage by then.
+func usageFlagError(err error) error {
+	if errors.Is(err, flag.ErrHelp) {
+		return err
+	}
+	return &usageError{err.Error()}
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  stations <command> [options]")
+	fmt.Fprintln(w, "  stations [-algorithm name] <map> <start> <end> <trains>")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'stations <command> --help' for the options of a command.")
+}
+
+// newFlagSet creates the flag set of a subcommand with a usage line listing its arguments.
+func newFlagSet(name, arguments string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: stations %s %s\n\nOptions:\n", name, arguments)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses the subcommand flags and rejects positional leftovers.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return usageFlagError(err)
+	}
+	if fs.NArg() > 0 {
+		return &usageError{fmt.Sprintf("unexpected argument: %s", fs.Arg(0))}
+	}
+	return nil
+}
+
+// requireFlags reports the first required string flag that was left empty.
+func requireFlags(fs *flag.FlagSet, names ...string) error {
+	for _, name := range names {
+		if fs.Lookup(name).Value.String() == "" {
+			return &usageError{fmt.Sprintf("--%s is required", name)}
+		}
+	}
+	return nil
+}
+
+// addLimitFlags registers the flags that set the limits deciding which maps are planned and how.
+func addLimitFlags(fs *flag.FlagSet) *train.Limits {
+	limits := train.DefaultLimits
+	fs.IntVar(&limits.MaxStations, "max-stations", limits.MaxStations, "reject maps with more than this many stations")
+	fs.IntVar(&limits.FlowThreshold, "flow-threshold", limits.FlowThreshold, "stations plus connections above which the auto algorithm uses flow")
+	fs.IntVar(&limits.MaxRoutes, "max-routes", limits.MaxRoutes, "estimated routes to enumerate above which the auto algorithm uses flow")
+	return &limits
+}
+
+func checkLimits(limits *train.Limits) error {
+	if limits.MaxStations < 1 || limits.FlowThreshold < 1 || limits.MaxRoutes < 1 {
+		return &usageError{"--max-stations, --flow-threshold and --max-routes must be positive"}
+	}
+	return nil
+}
+
+// newPlanner returns a planner running the named scheduler within the given limits.
+func newPlanner(algorithm string, limits train.Limits) (*train.Planner, error) {
+	scheduler, err := train.SchedulerByName(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := scheduler.(train.AutoScheduler); ok {
+		scheduler = train.AutoScheduler{Limits: limits}
+	}
+	return &train.Planner{Scheduler: scheduler, Limits: limits}, nil
+}
+
+// stdinPath is the map path that reads the map from standard input, called stdinName in messages.
+const (
+	stdinPath = "-"
+	stdinName = "stdin"
+)
+
+// mapName returns the name of the map at path for messages.
+func mapName(path string) string {
+	if path == stdinPath {
+		return stdinName
+	}
+	return path
+}
+
+// addParseFlags registers the flags that limit the lines of the maps read.
+func addParseFlags(fs *flag.FlagSet) *train.ParseOptions {
+	var options train.ParseOptions
+	fs.IntVar(&options.MaxLineLength, "max-line-length", train.DefaultMaxLineLength, "reject maps with a line longer than this many bytes")
+	fs.IntVar(&options.MaxLines, "max-lines", 0, "reject maps with more than this many lines, 0 for no limit")
+	return &options
+}
+
+func checkParseOptions(options train.ParseOptions) error {
+	if options.MaxLineLength < 1 || options.MaxLines < 0 {
+		return &usageError{"--max-line-length must be positive and --max-lines must not be negative"}
+	}
+	return nil
+}
+
+// parseMap parses the map file at path, or the map on standard input for "-".
+func parseMap(path string, options train.ParseOptions) (*train.Network, error) {
+	if err := checkParseOptions(options); err != nil {
+		return nil, err
+	}
+	if path == stdinPath {
+		return train.ParseNetwork(os.Stdin, options)
+	}
+	return train.ParseNetworkMapWithOptions(path, options)
+}
+
+// lintMap checks the map file at path, or the map on standard input for "-".
+func lintMap(path string, options train.ParseOptions) ([]train.Diagnostic, error) {
+	if err := checkParseOptions(options); err != nil {
+		return nil, err
+	}
+	if path == stdinPath {
+		return train.LintNetwork(os.Stdin, stdinName, options)
+	}
+	return train.LintNetworkMapWithOptions(path, options)
+}
+
+// reportDiagnostics prints every diagnostic about the named file and returns the number of
+// warnings, or an error counting the errors and warnings when any diagnostic is an error.
+func reportDiagnostics(stdout io.Writer, name string, diagnostics []train.Diagnostic) (int, error) {
+	errorCount, warningCount := 0, 0
+	for _, diagnostic := range diagnostics {
+		fmt.Fprintln(stdout, diagnostic)
+		if diagnostic.Severity == train.SeverityError {
+			errorCount++
+		} else {
+			warningCount++
+		}
+	}
+
+	if errorCount > 0 {
+		return warningCount, fmt.Errorf("%s: %d error(s), %d warning(s)", name, errorCount, warningCount)
+	}
+	return warningCount, nil
+}
+
+// demandList collects repeated --demand flags.
+type demandList []train.Demand
+
+func (d *demandList) String() string {
+	parts := make([]string, len(*d))
+	for i, demand := range *d {
+		parts[i] = fmt.Sprintf("%s,%s,%d", demand.From, demand.To, demand.Trains)
+	}
+	return strings.Join(parts, " ")
+}
+
+func (d *demandList) Set(value string) error {
+	fields := strings.Split(value, ",")
+	if len(fields) != 3 {
+		return fmt.Errorf("expected FROM,TO,N")
+	}
+	trains, err := strconv.Atoi(strings.TrimSpace(fields[2]))
+	if err := train.ValidateTrainCount(trains, err); err != nil {
+		return err
+	}
+	*d = append(*d, train.Demand{From: strings.TrimSpace(fields[0]), To: strings.TrimSpace(fields[1]), Trains: trains})
+	return nil
+}
+
+func runPlan(args []string, stdout io.Writer) error {
+	fs := newFlagSet("plan", "--map FILE (--from STATION --to STATION --trains N | --demand FROM,TO,N... | --trains-file FILE) [options]")
+	mapPath := fs.String("map", "", "network map `file`, may be gzip compressed, - for standard input (required)")
+	parseOptions := addParseFlags(fs)
+	from := fs.String("from", "", "start `station`")
+	to := fs.String("to", "", "end `station`")
+	trains := fs.Int("trains", 0, "number of trains")
+	var demands demandList
+	fs.Var(&demands, "demand", "send `FROM,TO,N` trains; repeat to plan several demands together instead of --from, --to and --trains")
+	trainsFile := fs.String("trains-file", "", "timetable `file` with one train per line, or a JSON array of trains, instead of --from, --to and --trains")
+	algorithm := fs.String("algorithm", "auto", "scheduling algorithm: auto, exhaustive, flow or greedy")
+	format := fs.String("format", "text", "output format: "+formatNames())
+	animate := fs.Bool("animate", false, "show the trains moving on a drawing of the map instead of printing the schedule")
+	delay := fs.Duration("delay", 500*time.Millisecond, "time between two turns of the animation")
+	width := fs.Int("width", 80, "maximum width of the animation in characters")
+	height := fs.Int("height", 24, "maximum height of the map in the animation in characters")
+	limits := addLimitFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := checkLimits(limits); err != nil {
+		return err
+	}
+	if err := requireFlags(fs, "map"); err != nil {
+		return err
+	}
+	if *animate && (*delay <= 0 || *width < 1 || *height < 1) {
+		return &usageError{"--delay, --width and --height must be positive"}
+	}
+	if len(demands) > 0 || *trainsFile != "" {
+		if *from != "" || *to != "" || *trains != 0 || (len(demands) > 0 && *trainsFile != "") {
+			return &usageError{"--demand and --trains-file cannot be combined with each other or with --from, --to or --trains"}
+		}
+	} else {
+		if err := requireFlags(fs, "from", "to"); err != nil {
+			return err
+		}
+		if err := train.ValidateTrainCount(*trains, nil); err != nil {
+			return err
+		}
+	}
+
+	writeSchedule, ok := scheduleFormats[*format]
+	if !ok {
+		return &usageError{fmt.Sprintf("unknown format: %s (expected one of %s)", *format, formatNames())}
+	}
+	planner, err := newPlanner(*algorithm, *limits)
+	if err != nil {
+		return err
+	}
+
+	var specs []train.TrainSpec
+	if *trainsFile != "" {
+		if specs, err = train.ParseTrainsFile(*trainsFile); err != nil {
+			return err
+		}
+	}
+
+	network, err := parseMap(*mapPath, *parseOptions)
+	if err != nil {
+		return err
+	}
+
+	var schedule *train.Schedule
+	switch {
+	case *trainsFile != "":
+		schedule, err = planner.PlanTimetable(context.Background(), network, specs)
+	case len(demands) > 0:
+		schedule, err = planner.PlanDemands(context.Background(), network, demands)
+	default:
+		schedule, err = planner.Plan(context.Background(), network, *from, *to, *trains)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *animate {
+		runAnimation(stdout, newAnimation(network, schedule, *width, *height, *delay))
+		return nil
+	}
+	return writeSchedule(stdout, schedule)
+}
+
+func runValidate(args []string, stdout io.Writer) error {
+	fs := newFlagSet("validate", "--map FILE")
+	mapPath := fs.String("map", "", "network map `file`, may be gzip compressed, - for standard input (required)")
+	parseOptions := addParseFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := requireFlags(fs, "map"); err != nil {
+		return err
+	}
+
+	diagnostics, err := lintMap(*mapPath, *parseOptions)
+	if err != nil {
+		return err
+	}
+
+	warningCount, err := reportDiagnostics(stdout, mapName(*mapPath), diagnostics)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "%s: ok (%d warning(s))\n", mapName(*mapPath), warningCount)
+	return nil
+}
+
+func runVerify(args []string, stdout io.Writer) error {
+	fs := newFlagSet("verify", "--map FILE --from STATION --to STATION --trains N [--algorithm NAME] SCHEDULE")
+	mapPath := fs.String("map", "", "network map `file`, may be gzip compressed, - for standard input (required)")
+	parseOptions := addParseFlags(fs)
+	from := fs.String("from", "", "start `station` (required)")
+	to := fs.String("to", "", "end `station` (required)")
+	trains := fs.Int("trains", 0, "number of trains (required)")
+	algorithm := fs.String("algorithm", "auto", "scheduling algorithm giving the turn count to compare with: auto, exhaustive, flow or greedy")
+	if err := fs.Parse(args); err != nil {
+		return usageFlagError(err)
+	}
+	if fs.NArg() != 1 {
+		return &usageError{"expected exactly one schedule file after the options"}
+	}
+	schedulePath := fs.Arg(0)
+	if err := requireFlags(fs, "map", "from", "to"); err != nil {
+		return err
+	}
+	if err := train.ValidateTrainCount(*trains, nil); err != nil {
+		return err
+	}
+	planner, err := newPlanner(*algorithm, train.DefaultLimits)
+	if err != nil {
+		return err
+	}
+
+	network, err := parseMap(*mapPath, *parseOptions)
+	if err != nil {
+		return err
+	}
+	optimum, err := planner.Plan(context.Background(), network, *from, *to, *trains)
+	if err != nil {
+		return err
+	}
+
+	diagnostics, turns, err := train.VerifySchedule(schedulePath, network, *from, *to, *trains, len(optimum.Turns))
+	if err != nil {
+		return err
+	}
+
+	if _, err := reportDiagnostics(stdout, schedulePath, diagnostics); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "%s: ok, %d turns (%s plans %d)\n", schedulePath, turns, *algorithm, len(optimum.Turns))
+	return nil
+}
+
+func runInfo(args []string, stdout io.Writer) error {
+	fs := newFlagSet("info", "--map FILE [options]")
+	mapPath := fs.String("map", "", "network map `file`, may be gzip compressed, - for standard input (required)")
+	parseOptions := addParseFlags(fs)
+	limits := addLimitFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := checkLimits(limits); err != nil {
+		return err
+	}
+	if err := requireFlags(fs, "map"); err != nil {
+		return err
+	}
+
+	network, err := parseMap(*mapPath, *parseOptions)
+	if err != nil {
+		return err
+	}
+
+	// Degrees count connections in either direction, so one-way connections count at both ends
+	degrees := make(map[string]int, len(network.Stations))
+	oneWay := 0
+	for _, conn := range network.Connections {
+		degrees[conn.From]++
+		degrees[conn.To]++
+		if conn.OneWay {
+			oneWay++
+		}
+	}
+
+	isolated := 0
+	minDegree, maxDegree := -1, 0
+	for _, station := range network.Stations {
+		degree := degrees[station.Name]
+		if degree == 0 {
+			isolated++
+		}
+		if minDegree == -1 || degree < minDegree {
+			minDegree = degree
+		}
+		if degree > maxDegree {
+			maxDegree = degree
+		}
+	}
+	if minDegree == -1 {
+		minDegree = 0
+	}
+
+	fmt.Fprintf(stdout, "stations:    %d\n", len(network.Stations))
+	fmt.Fprintf(stdout, "connections: %d (%d one-way)\n", len(network.Connections), oneWay)
+	fmt.Fprintf(stdout, "isolated:    %d\n", isolated)
+	fmt.Fprintf(stdout, "degree:      min %d, max %d\n", minDegree, maxDegree)
+	if len(network.Stations) > 0 {
+		minX, minY, maxX, maxY := train.Bounds(network.Stations)
+		fmt.Fprintf(stdout, "bounds:      x %d..%d, y %d..%d\n", minX, maxX, minY, maxY)
+	}
+
+	size := train.MeasureNetwork(network)
+	fmt.Fprintf(stdout, "cycles:      %d (up to 2^%d routes between two stations)\n", size.Cycles, size.Cycles)
+	if err := limits.Check(size); err != nil {
+		fmt.Fprintf(stdout, "limit:       %v\n", err)
+	} else {
+		fmt.Fprintf(stdout, "limit:       ok, %d of at most %d stations\n", size.Stations, limits.MaxStations)
+	}
+	name, reason := limits.Choose(size)
+	fmt.Fprintf(stdout, "auto:        %s (%s)\n", name, reason)
+	return nil
+}
+
+// drawingFormats are the image formats of the render command besides text.
+var drawingFormats = map[string]func(w io.Writer, network *train.Network, routes [][]string, trains []int, size int) error{
+	"svg": train.WriteSVG,
+	"png": train.WritePNG,
+}
+
+func runRender(args []string, stdout io.Writer) error {
+	fs := newFlagSet("render", "--map FILE [--format text|svg|png] [--from STATION --to STATION --trains N] [options]")
+	mapPath := fs.String("map", "", "network map `file`, may be gzip compressed, - for standard input (required)")
+	parseOptions := addParseFlags(fs)
+	format := fs.String("format", "text", "output format: text, svg or png")
+	output := fs.String("output", "", "write the drawing to `file` instead of standard output")
+	width := fs.Int("width", 80, "maximum width of the text drawing in characters")
+	height := fs.Int("height", 40, "maximum height of the text drawing in characters")
+	size := fs.Int("size", 800, "maximum width or height of the svg or png map in pixels")
+	from := fs.String("from", "", "plan trains from this `station` and highlight their routes (svg and png)")
+	to := fs.String("to", "", "end `station` of the highlighted routes")
+	trains := fs.Int("trains", 0, "number of trains of the highlighted routes")
+	algorithm := fs.String("algorithm", "auto", "scheduling algorithm of the highlighted routes: auto, exhaustive, flow or greedy")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := requireFlags(fs, "map"); err != nil {
+		return err
+	}
+	if *width < 1 || *height < 1 || *size < 1 {
+		return &usageError{"--width, --height and --size must be positive"}
+	}
+	writeDrawing, ok := drawingFormats[*format]
+	if !ok && *format != "text" {
+		return &usageError{fmt.Sprintf("unknown format: %s (expected one of png, svg, text)", *format)}
+	}
+	highlight, err := routeFlagsGiven(fs, *from, *to, *trains)
+	if err != nil {
+		return err
+	}
+	if highlight && *format == "text" {
+		return &usageError{"--from, --to and --trains need --format svg or png"}
+	}
+	planner, err := newPlanner(*algorithm, train.DefaultLimits)
+	if err != nil {
+		return err
+	}
+
+	network, err := parseMap(*mapPath, *parseOptions)
+	if err != nil {
+		return err
+	}
+
+	var routes [][]string
+	var trainsPerRoute []int
+	if highlight {
+		if routes, trainsPerRoute, err = plannedRoutes(network, planner, *from, *to, *trains); err != nil {
+			return err
+		}
+	}
+
+	draw := func(w io.Writer) error {
+		if writeDrawing == nil {
+			_, err := io.WriteString(w, train.NewGrid(network, *width, *height).String())
+			return err
+		}
+		return writeDrawing(w, network, routes, trainsPerRoute, *size)
+	}
+	if *output == "" {
+		return draw(stdout)
+	}
+	return writeFile(*output, draw)
+}
+
+func runExport(args []string, stdout io.Writer) error {
+	fs := newFlagSet("export", "--map FILE --format dot [--from STATION --to STATION --trains N] [options]")
+	mapPath := fs.String("map", "", "network map `file`, may be gzip compressed, - for standard input (required)")
+	parseOptions := addParseFlags(fs)
+	format := fs.String("format", "dot", "output format: dot")
+	output := fs.String("output", "", "write the export to `file` instead of standard output")
+	from := fs.String("from", "", "plan trains from this `station` and colour the connections of their routes")
+	to := fs.String("to", "", "end `station` of the coloured routes")
+	trains := fs.Int("trains", 0, "number of trains of the coloured routes, shown on every connection they use")
+	algorithm := fs.String("algorithm", "auto", "scheduling algorithm of the coloured routes: auto, exhaustive, flow or greedy")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := requireFlags(fs, "map"); err != nil {
+		return err
+	}
+	if *format != "dot" {
+		return &usageError{fmt.Sprintf("unknown format: %s (expected dot)", *format)}
+	}
+	highlight, err := routeFlagsGiven(fs, *from, *to, *trains)
+	if err != nil {
+		return err
+	}
+	planner, err := newPlanner(*algorithm, train.DefaultLimits)
+	if err != nil {
+		return err
+	}
+
+	network, err := parseMap(*mapPath, *parseOptions)
+	if err != nil {
+		return err
+	}
+
+	var routes [][]string
+	var trainsPerRoute []int
+	if highlight {
+		if routes, trainsPerRoute, err = plannedRoutes(network, planner, *from, *to, *trains); err != nil {
+			return err
+		}
+	}
+
+	write := func(w io.Writer) error {
+		return train.WriteDOT(w, network, routes, trainsPerRoute)
+	}
+	if *output == "" {
+		return write(stdout)
+	}
+	return writeFile(*output, write)
+}
+
+func runImport(args []string, stdout io.Writer) error {
+	fs := newFlagSet("import", "--gtfs FILE [options]")
+	feedPath := fs.String("gtfs", "", "GTFS feed zip `file`, - for standard input (required)")
+	scale := fs.Int("scale", train.DefaultGTFSScale, "`metres` per map coordinate")
+	routes := fs.String("routes", "", "comma separated route_id `list` of the routes to import (default all)")
+	output := fs.String("output", "", "write the map to `file` instead of standard output")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := requireFlags(fs, "gtfs"); err != nil {
+		return err
+	}
+	if *scale < 1 {
+		return &usageError{fmt.Sprintf("--scale must be positive, got %d", *scale)}
+	}
+
+	options := train.GTFSOptions{Scale: *scale}
+	if *routes != "" {
+		for _, route := range strings.Split(*routes, ",") {
+			options.Routes = append(options.Routes, strings.TrimSpace(route))
+		}
+	}
+	network, err := parseFeed(*feedPath, options)
+	if err != nil {
+		return err
+	}
+
+	write := func(w io.Writer) error {
+		return train.WriteNetwork(w, network)
+	}
+	if *output == "" {
+		return write(stdout)
+	}
+	return writeFile(*output, write)
+}
+
+// parseFeed reads the GTFS feed at path, or the feed on standard input for "-".
+func parseFeed(path string, options train.GTFSOptions) (*train.Network, error) {
+	if path != stdinPath {
+		return train.ParseGTFS(path, options)
+	}
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return nil, err
+	}
+	return train.ReadGTFS(bytes.NewReader(data), int64(len(data)), options)
+}
+
+// routeFlagsGiven reports whether routes to highlight were asked for, in which case
+// --from, --to and --trains must all be valid.
+func routeFlagsGiven(fs *flag.FlagSet, from, to string, trains int) (bool, error) {
+	if from == "" && to == "" && trains == 0 {
+		return false, nil
+	}
+	if err := requireFlags(fs, "from", "to"); err != nil {
+		return false, err
+	}
+	if err := train.ValidateTrainCount(trains, nil); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// plannedRoutes plans the trains and returns the routes they use, each starting with the
+// start station, together with the number of trains on each route.
+func plannedRoutes(network *train.Network, planner *train.Planner, from, to string, trains int) ([][]string, []int, error) {
+	schedule, err := planner.Plan(context.Background(), network, from, to, trains)
+	if err != nil {
+		return nil, nil, err
+	}
+	return schedule.StationRoutes(), schedule.Stats().TrainsPerRoute, nil
+}
+
+// writeFile creates the file at path and writes it with write.
+func writeFile(path string, write func(io.Writer) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func formatNames() string {
+	names := make([]string, 0, len(scheduleFormats))
+	for name := range scheduleFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
@@ -1,59 +1,126 @@
 package main
 
 import (
-	"bufio"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
 	train "stations/pkg"
-	train2 "stations/pkg2"
+	"strconv"
 )
 
-func countStations(filePath string) (int, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return 0, err
+// Exit codes of the command.
+const (
+	exitOK      = 0
+	exitFailure = 1 // The map is invalid or no schedule could be planned
+	exitUsage   = 2 // The command line itself is wrong
+)
+
+// exitCode maps an error to the exit status of the process.
+// Library code only returns errors, so this is the single place where the exit status is decided.
+func exitCode(err error) int {
+	var usage *usageError
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.As(err, &usage),
+		errors.Is(err, train.ErrTooFewArguments),
+		errors.Is(err, train.ErrTooManyArguments),
+		errors.Is(err, train.ErrInvalidExtraArg),
+		errors.Is(err, train.ErrInvalidTrainCount),
+		errors.Is(err, train.ErrUnknownScheduler):
+		return exitUsage
+	default:
+		return exitFailure
+	}
+}
+
+func main() {
+	err := run(os.Args[1:], os.Stdout)
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+	}
+	os.Exit(exitCode(err))
+}
+
+// run dispatches to a subcommand, or to the positional form when the first argument is not one.
+func run(args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		printUsage(os.Stderr)
+		return &usageError{"no command given"}
 	}
-	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
-	count := 0
-	for scanner.Scan() {
-		count++
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		printUsage(stdout)
+		return nil
 	}
-	if err := scanner.Err(); err != nil {
-		return 0, err
+
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:], stdout)
+		}
 	}
 
-	return count, nil
+	return runPositional(args, stdout)
 }
 
-func main() {
-	if len(os.Args) < 2 {
-		fmt.Println("Error: Please provide the file path to the station map.")
-		os.Exit(1)
+// runPositional handles the original command line: [-algorithm name] <map> <start> <end> <trains> [extra|bonus].
+func runPositional(arguments []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("stations", flag.ContinueOnError)
+	algorithm := fs.String("algorithm", "auto", "scheduling algorithm: auto, exhaustive, flow or greedy")
+	limits := addLimitFlags(fs)
+	parseOptions := addParseFlags(fs)
+	if err := fs.Parse(arguments); err != nil {
+		return usageFlagError(err)
+	}
+	if err := checkLimits(limits); err != nil {
+		return err
+	}
+	args := append([]string{os.Args[0]}, fs.Args()...)
+
+	if len(args) < 2 {
+		return &usageError{"Please provide the file path to the station map."}
+	}
+
+	filePath := args[1]
+
+	if err := train.CheckArguments(args); err != nil {
+		return err
 	}
 
-	filePath := os.Args[1]
+	startStation := args[2]
+	endStation := args[3]
+	numTrains, err := strconv.Atoi(args[4])
+	if err := train.ValidateTrainCount(numTrains, err); err != nil {
+		return err
+	}
+
+	if len(args) > 5 {
+		if err := train.ValidateExtraArgs(args[5:]); err != nil {
+			return err
+		}
+		for _, arg := range args[5:] {
+			fmt.Fprintln(stdout, "Handling extra argument:", arg)
+		}
+	}
 
-	// Counting the number of stations in the file
-	stationCount, err := countStations(filePath)
+	planner, err := newPlanner(*algorithm, *limits)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: Unable to count stations: %v\n", err)
-		os.Exit(1)
+		return err
 	}
 
-	// Checking if the number of stations exceeds 10000
-	if stationCount > 10000 {
-		fmt.Println("Error: Map contains more than 10000 stations")
-		os.Exit(1)
+	network, err := parseMap(filePath, *parseOptions)
+	if err != nil {
+		return err
 	}
 
-	// Checking the number of stations and running the appropriate code
-	if stationCount > 5000 {
-		//fmt.Println("Launching Muvmain because the number of stations is greater than 5000")
-		train2.Muvmain()
-	} else {
-		//fmt.Println("Launching Logmain because the number of stations is less than or equal to 5000")
-		train.Logmain()
+	schedule, err := planner.Plan(context.Background(), network, startStation, endStation, numTrains)
+	if err != nil {
+		return err
 	}
+
+	return train.WriteText(stdout, schedule)
 }
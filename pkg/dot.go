@@ -0,0 +1,79 @@
+package train
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WriteDOT writes the network as a Graphviz graph. Every station gets a pinned pos attribute from
+// its coordinates, in inches with y pointing up as Graphviz expects, so "neato" draws the map as
+// the coordinates place it. One-way connections are drawn with an arrow and weighted ones are
+// labelled with their weight.
+//
+// Routes, when given, list their stations including the first one. The connections they use
+// are coloured like the routes of WriteSVG and, when trains is not nil, labelled with the
+// number of trains travelling them.
+func WriteDOT(w io.Writer, network *Network, routes [][]string, trains []int) error {
+	// Routes travelling every connection, keyed by the direction travelled
+	used := make(map[[2]string][]int)
+	for routeIdx, route := range routes {
+		for i := 1; i < len(route); i++ {
+			hop := [2]string{route[i-1], route[i]}
+			used[hop] = append(used[hop], routeIdx)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("graph stations {\n")
+	b.WriteString("  node [shape=point, width=0.1, fontsize=10];\n")
+	for _, station := range network.Stations {
+		fmt.Fprintf(&b, "  %s [pos=\"%d,%d!\", xlabel=%s];\n",
+			strconv.Quote(station.Name), station.X, -station.Y, strconv.Quote(station.Name))
+	}
+
+	for _, conn := range network.Connections {
+		var attrs []string
+		if conn.OneWay {
+			attrs = append(attrs, "dir=forward")
+		}
+
+		routeIdxs := used[[2]string{conn.From, conn.To}]
+		if !conn.OneWay {
+			routeIdxs = append(append([]int(nil), routeIdxs...), used[[2]string{conn.To, conn.From}]...)
+		}
+
+		var labels []string
+		if conn.Weight > 1 {
+			labels = append(labels, fmt.Sprintf("w=%d", conn.Weight))
+		}
+		if len(routeIdxs) > 0 {
+			colours := make([]string, len(routeIdxs))
+			count := 0
+			for i, routeIdx := range routeIdxs {
+				colours[i] = routeColor(routeIdx)
+				if trains != nil {
+					count += trains[routeIdx]
+				}
+			}
+			attrs = append(attrs, fmt.Sprintf("color=%s", strconv.Quote(strings.Join(colours, ":"))), "penwidth=3")
+			if trains != nil {
+				labels = append(labels, fmt.Sprintf("%d trains", count))
+			}
+		}
+		if len(labels) > 0 {
+			attrs = append(attrs, "label="+strconv.Quote(strings.Join(labels, ", ")))
+		}
+
+		fmt.Fprintf(&b, "  %s -- %s", strconv.Quote(conn.From), strconv.Quote(conn.To))
+		if len(attrs) > 0 {
+			fmt.Fprintf(&b, " [%s]", strings.Join(attrs, ", "))
+		}
+		b.WriteString(";\n")
+	}
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
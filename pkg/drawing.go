@@ -0,0 +1,139 @@
+package train
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+// RouteColors are the colours given to highlighted routes, in order.
+var RouteColors = []string{"#e6194b", "#3cb44b", "#4363d8", "#f58231", "#911eb4", "#42d4f4", "#f032e6", "#9a6324"}
+
+// Layout of a drawing, in pixels.
+const (
+	drawingMargin  = 40
+	stationRadius  = 5
+	legendLine     = 18
+	trackWidth     = 2
+	routeWidth     = 5
+	labelOffset    = 8
+	labelCharWidth = 8 // Width of a label character, to leave room for labels on the right
+	legendIndent   = 28
+)
+
+// drawing places the stations of a network and a set of highlighted routes on a canvas.
+// Every highlighted route lists its stations including the first one.
+type drawing struct {
+	network *Network
+	routes  [][]string
+	trains  []int // Trains sent along each route, or nil
+	points  map[string][2]int
+	width   int
+	height  int // Height of the map, without the legend
+}
+
+// newDrawing scales the station coordinates so that the map is at most size pixels wide or high.
+func newDrawing(network *Network, routes [][]string, trains []int, size int) *drawing {
+	minX, minY, maxX, maxY := Bounds(network.Stations)
+	longestName := 0
+	for _, station := range network.Stations {
+		longestName = max(longestName, len([]rune(station.Name)))
+	}
+
+	// One scale for both axes keeps the proportions of the map
+	span := max(maxX-minX, maxY-minY, 1)
+	inner := max(size-2*drawingMargin, 1)
+
+	d := &drawing{
+		network: network,
+		routes:  routes,
+		trains:  trains,
+		points:  make(map[string][2]int, len(network.Stations)),
+		width:   (maxX-minX)*inner/span + 2*drawingMargin + longestName*labelCharWidth,
+		height:  (maxY-minY)*inner/span + 2*drawingMargin,
+	}
+	for routeIdx := range routes {
+		d.width = max(d.width, drawingMargin+legendIndent+len([]rune(d.legend(routeIdx)))*labelCharWidth+drawingMargin)
+	}
+	for _, station := range network.Stations {
+		d.points[station.Name] = [2]int{
+			drawingMargin + (station.X-minX)*inner/span,
+			drawingMargin + (station.Y-minY)*inner/span,
+		}
+	}
+	return d
+}
+
+// totalHeight returns the height of the map together with its legend.
+func (d *drawing) totalHeight() int {
+	return d.height + len(d.routes)*legendLine
+}
+
+// legend returns the legend text of a highlighted route.
+func (d *drawing) legend(routeIdx int) string {
+	text := fmt.Sprintf("Route %d: %s", routeIdx+1, strings.Join(d.routes[routeIdx], " - "))
+	if d.trains != nil {
+		text += fmt.Sprintf(" (%d trains)", d.trains[routeIdx])
+	}
+	return text
+}
+
+func routeColor(routeIdx int) string {
+	return RouteColors[routeIdx%len(RouteColors)]
+}
+
+// WriteSVG draws the network as an SVG image with every station at its coordinates and each of
+// the given routes highlighted in its own colour. Routes list their stations including the first
+// one, and trains, when not nil, gives the number of trains sent along each route for the legend.
+func WriteSVG(w io.Writer, network *Network, routes [][]string, trains []int, size int) error {
+	d := newDrawing(network, routes, trains, size)
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n", d.width, d.totalHeight(), d.width, d.totalHeight())
+	b.WriteString("<defs><marker id=\"arrow\" viewBox=\"0 0 10 10\" refX=\"16\" refY=\"5\" markerWidth=\"6\" markerHeight=\"6\" orient=\"auto\"><path d=\"M0,0 L10,5 L0,10 z\" fill=\"#999\"/></marker></defs>\n")
+	b.WriteString("<rect width=\"100%\" height=\"100%\" fill=\"white\"/>\n")
+
+	fmt.Fprintf(&b, "<g stroke=\"#999\" stroke-width=\"%d\">\n", trackWidth)
+	for _, conn := range network.Connections {
+		from, okFrom := d.points[conn.From]
+		to, okTo := d.points[conn.To]
+		if !okFrom || !okTo {
+			continue
+		}
+		marker := ""
+		if conn.OneWay {
+			marker = " marker-end=\"url(#arrow)\""
+		}
+		fmt.Fprintf(&b, "  <line x1=\"%d\" y1=\"%d\" x2=\"%d\" y2=\"%d\"%s/>\n", from[0], from[1], to[0], to[1], marker)
+	}
+	b.WriteString("</g>\n")
+
+	// Later routes are drawn thinner on top of earlier ones, so shared connections show every colour
+	for routeIdx, route := range routes {
+		points := make([]string, 0, len(route))
+		for _, station := range route {
+			if point, ok := d.points[station]; ok {
+				points = append(points, fmt.Sprintf("%d,%d", point[0], point[1]))
+			}
+		}
+		width := max(routeWidth+len(routes)-1-routeIdx, 2)
+		fmt.Fprintf(&b, "<polyline points=\"%s\" fill=\"none\" stroke=\"%s\" stroke-width=\"%d\" stroke-linejoin=\"round\" stroke-opacity=\"0.85\"/>\n", strings.Join(points, " "), routeColor(routeIdx), width)
+	}
+
+	b.WriteString("<g font-family=\"sans-serif\" font-size=\"12\">\n")
+	for _, station := range network.Stations {
+		point := d.points[station.Name]
+		fmt.Fprintf(&b, "  <circle cx=\"%d\" cy=\"%d\" r=\"%d\" fill=\"white\" stroke=\"black\" stroke-width=\"2\"/>\n", point[0], point[1], stationRadius)
+		fmt.Fprintf(&b, "  <text x=\"%d\" y=\"%d\">%s</text>\n", point[0]+labelOffset, point[1]-labelOffset, html.EscapeString(station.Name))
+	}
+	for routeIdx := range routes {
+		y := d.height + routeIdx*legendLine
+		fmt.Fprintf(&b, "  <rect x=\"%d\" y=\"%d\" width=\"20\" height=\"6\" fill=\"%s\"/>\n", drawingMargin, y, routeColor(routeIdx))
+		fmt.Fprintf(&b, "  <text x=\"%d\" y=\"%d\">%s</text>\n", drawingMargin+legendIndent, y+7, html.EscapeString(d.legend(routeIdx)))
+	}
+	b.WriteString("</g>\n</svg>\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
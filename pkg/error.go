@@ -1,27 +1,128 @@
 package train
 
 import (
+	"errors"
 	"fmt"
-	"os"
+	"strings"
 )
 
-// Error function prints the error message and exits the program.
-func Error(message string) {
-	fmt.Fprintln(os.Stderr, "Error:", message)
-	os.Exit(1)
+var (
+	ErrTooFewArguments   = errors.New("Too few command line arguments")
+	ErrTooManyArguments  = errors.New("Too many command line arguments")
+	ErrInvalidExtraArg   = errors.New("Invalid extra argument")
+	ErrInvalidTrainCount = errors.New("Invalid number of trains")
+	ErrSameStation       = errors.New("Start and end station are the same")
+	ErrUnknownStation    = errors.New("unknown station")
+	ErrNoPath            = errors.New("no path found")
+	ErrMissingSection    = errors.New("missing map section")
+	ErrTooManyStations   = errors.New("Map contains too many stations")
+	ErrMapTooLarge       = errors.New("map is too large")
+	ErrUnknownScheduler  = errors.New("unknown algorithm")
+	ErrDeadlock          = errors.New("trains block each other")
+)
+
+// ErrDuplicateConnection reports a connection listed twice, in either direction.
+type ErrDuplicateConnection struct {
+	A string
+	B string
+}
+
+func (e *ErrDuplicateConnection) Error() string {
+	return fmt.Sprintf("duplicate connection between %s and %s", e.A, e.B)
+}
+
+// ErrDuplicateStation reports a station name listed twice.
+type ErrDuplicateStation struct {
+	Name string
+}
+
+func (e *ErrDuplicateStation) Error() string {
+	return fmt.Sprintf("duplicate station name detected: %s", e.Name)
+}
+
+// ErrDuplicateTrain reports a train name given to two trains of a timetable.
+type ErrDuplicateTrain struct {
+	Name string
+}
+
+func (e *ErrDuplicateTrain) Error() string {
+	return fmt.Sprintf("duplicate train name: %s", e.Name)
+}
+
+// ErrDuplicateCoordinates reports two stations placed at the same coordinates.
+type ErrDuplicateCoordinates struct {
+	X int
+	Y int
+}
+
+func (e *ErrDuplicateCoordinates) Error() string {
+	return fmt.Sprintf("two stations exist at the same coordinates: %d, %d", e.X, e.Y)
+}
+
+// ErrInvalidLine reports a map line that could not be parsed.
+type ErrInvalidLine struct {
+	Reason string // What was invalid, e.g. "station coordinates"
+	Text   string
+	Line   int
+	Col    int
+}
+
+func (e *ErrInvalidLine) Error() string {
+	return fmt.Sprintf("invalid %s on line %d: %s", e.Reason, e.Line, e.Text)
+}
+
+// MissedDeadline is a train that arrives after its deadline.
+type MissedDeadline struct {
+	Train    string
+	Deadline int
+	Arrival  int // Turn the train arrives on
+}
+
+// ErrMissedDeadlines reports every train of a timetable that cannot meet its deadline.
+type ErrMissedDeadlines struct {
+	Missed []MissedDeadline
+}
+
+func (e *ErrMissedDeadlines) Error() string {
+	parts := make([]string, len(e.Missed))
+	for i, missed := range e.Missed {
+		parts[i] = fmt.Sprintf("%s arrives on turn %d, deadline %d", missed.Train, missed.Arrival, missed.Deadline)
+	}
+	return fmt.Sprintf("%d train(s) miss their deadline: %s", len(e.Missed), strings.Join(parts, "; "))
+}
+
+// detailedError carries a readable message while still matching a sentinel error with errors.Is.
+type detailedError struct {
+	sentinel error
+	message  string
 }
 
+func (e *detailedError) Error() string { return e.message }
+
+func (e *detailedError) Unwrap() error { return e.sentinel }
+
 // CheckArguments validates the number of command line arguments.
-func CheckArguments(args []string) {
+func CheckArguments(args []string) error {
 	if len(args) < 5 {
-		Error("Too few command line arguments")
+		return ErrTooFewArguments
 	} else if len(args) > 6 {
-		Error("Too many command line arguments")
+		return ErrTooManyArguments
 	}
+	return nil
+}
+
+// ValidateExtraArgs checks that only the supported extra arguments are given.
+func ValidateExtraArgs(args []string) error {
+	for _, arg := range args {
+		if arg != "extra" && arg != "bonus" {
+			return &detailedError{ErrInvalidExtraArg, fmt.Sprintf("Invalid extra argument: %s", arg)}
+		}
+	}
+	return nil
 }
 
 // ValidateStationExistence checks if the start and end stations exist in the map.
-func ValidateStationExistence(stations []Station, startStation, endStation string) {
+func ValidateStationExistence(stations []Station, startStation, endStation string) error {
 	startExists := false
 	endExists := false
 
@@ -37,51 +138,54 @@ func ValidateStationExistence(stations []Station, startStation, endStation strin
 
 	// Check if start station exists
 	if !startExists {
-		Error(fmt.Sprintf("Start station does not exist: %s", startStation))
+		return &detailedError{ErrUnknownStation, fmt.Sprintf("Start station does not exist: %s", startStation)}
 	}
 
 	// Check if end station exists
 	if !endExists {
-		Error(fmt.Sprintf("End station does not exist: %s", endStation))
+		return &detailedError{ErrUnknownStation, fmt.Sprintf("End station does not exist: %s", endStation)}
 	}
+	return nil
 }
 
 // ValidateDifferentStations checks that start and end stations are not the same.
-func ValidateDifferentStations(startStation, endStation string) {
+func ValidateDifferentStations(startStation, endStation string) error {
 	if startStation == endStation {
-		Error("Start and end station are the same")
+		return ErrSameStation
 	}
+	return nil
 }
 
 // ValidatePathExistence checks if there is a valid path between start and end stations.
-func ValidatePathExistence(path [][]string, startStation, endStation string) {
+func ValidatePathExistence(path [][]string, startStation, endStation string) error {
 	if len(path) == 0 {
-		Error(fmt.Sprintf("No path found from %s to %s", startStation, endStation))
+		return &detailedError{ErrNoPath, fmt.Sprintf("No path found from %s to %s", startStation, endStation)}
 	}
+	return nil
 }
 
 // ValidateTrainCount checks if the number of trains is a valid positive integer.
-func ValidateTrainCount(numTrains int, err error) {
+func ValidateTrainCount(numTrains int, err error) error {
 	if err != nil || numTrains <= 0 {
-		Error("Invalid number of trains")
+		return ErrInvalidTrainCount
 	}
+	return nil
 }
 
-// CheckDuplicateRoutes checks for duplicate connections, including reversed ones, in a slice of connection pairs.
-func CheckDuplicateRoutes(connections [][]string) error {
-	seen := make(map[string]bool)
+// CheckDuplicateRoutes checks for connections that allow travel in a direction an earlier
+// connection already allows. Reversed two-way connections are duplicates, while the one-way
+// connections a->b and b->a are not.
+func CheckDuplicateRoutes(connections []Connection) error {
+	seen := make(map[[2]string]bool)
 	for _, conn := range connections {
-		if len(conn) != 2 {
-			return fmt.Errorf("invalid connection format: %v", conn)
+		for _, direction := range conn.Directions() {
+			if seen[direction] {
+				return &ErrDuplicateConnection{A: conn.From, B: conn.To}
+			}
 		}
-		from := conn[0]
-		to := conn[1]
-		forward := fmt.Sprintf("%s-%s", from, to)
-		reverse := fmt.Sprintf("%s-%s", to, from)
-		if seen[forward] || seen[reverse] {
-			return fmt.Errorf("duplicate connection between %s and %s", from, to)
+		for _, direction := range conn.Directions() {
+			seen[direction] = true
 		}
-		seen[forward] = true
 	}
 	return nil
 }
@@ -89,10 +193,10 @@ func CheckDuplicateRoutes(connections [][]string) error {
 // CheckSections validates that both "stations:" and "connections:" sections are present in the map.
 func CheckSections(stationsSectionFound, connectionsSectionFound bool) error {
 	if !stationsSectionFound {
-		return fmt.Errorf("map does not contain a 'stations:' section")
+		return &detailedError{ErrMissingSection, "map does not contain a 'stations:' section"}
 	}
 	if !connectionsSectionFound {
-		return fmt.Errorf("map does not contain a 'connections:' section")
+		return &detailedError{ErrMissingSection, "map does not contain a 'connections:' section"}
 	}
 	return nil
 }
@@ -103,8 +207,7 @@ func CheckDuplicateStationNames(stations []Station) error {
 	for _, station := range stations {
 		name := station.Name
 		if seen[name] {
-			// Output error message to standard error output
-			return fmt.Errorf("duplicate station name detected: %s", name)
+			return &ErrDuplicateStation{Name: name}
 		}
 		seen[name] = true
 	}
@@ -113,35 +216,31 @@ func CheckDuplicateStationNames(stations []Station) error {
 
 // CheckDuplicateCoordinates checks if any two stations have the same coordinates.
 func CheckDuplicateCoordinates(stations []Station) error {
-	coordsMap := make(map[string]bool)
+	coordsMap := make(map[[2]int]bool, len(stations))
 	for _, station := range stations {
-		coords := fmt.Sprintf("%d,%d", station.X, station.Y)
+		coords := [2]int{station.X, station.Y}
 		if coordsMap[coords] {
-			return fmt.Errorf("two stations exist at the same coordinates: %d, %d", station.X, station.Y)
+			return &ErrDuplicateCoordinates{X: station.X, Y: station.Y}
 		}
 		coordsMap[coords] = true
 	}
 	return nil
 }
 
-func CheckConnectionsExist(stations []Station, connections [][]string) error {
-	// Helper function to check if a station exists in the slice
-	exists := func(name string) bool {
-		for _, station := range stations {
-			if station.Name == name {
-				return true
-			}
-		}
-		return false
+// CheckConnectionsExist validates that all connections refer to existing stations.
+func CheckConnectionsExist(stations []Station, connections []Connection) error {
+	exists := make(map[string]bool, len(stations))
+	for _, station := range stations {
+		exists[station.Name] = true
 	}
 
 	// Iterate through the connections and validate station existence
 	for _, conn := range connections {
-		if !exists(conn[0]) {
-			return fmt.Errorf("Error: Connection from unknown station: %s", conn[0])
+		if !exists[conn.From] {
+			return &detailedError{ErrUnknownStation, fmt.Sprintf("Connection from unknown station: %s", conn.From)}
 		}
-		if !exists(conn[1]) {
-			return fmt.Errorf("Error: Connection to unknown station: %s", conn[1])
+		if !exists[conn.To] {
+			return &detailedError{ErrUnknownStation, fmt.Sprintf("Connection to unknown station: %s", conn.To)}
 		}
 	}
 
@@ -0,0 +1,304 @@
+package train
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// FlowScheduler finds vertex-disjoint route sets with a node-split min-cost flow.
+// Each augmentation adds one route, either keeping the total route duration minimal or
+// keeping the routes found before. Since trains follow each other on a connection only as
+// fast as its headway allows, the search is repeated with slow connections made more costly.
+// The routes found and the fastest few routes are then combined, and the combination on which
+// the simulated trains arrive soonest is kept. It stays fast on networks with thousands of
+// stations where the exhaustive optimizer cannot finish.
+type FlowScheduler struct{}
+
+func (FlowScheduler) Schedule(ctx context.Context, network *Network, start, end string, trains int) (*Schedule, error) {
+	routes, err := FindDisjointRoutes(ctx, network, start, end, trains)
+	if err != nil {
+		return nil, err
+	}
+
+	return PlanTrainMovements(routes, RouteTimer{Graph: NewGraph(network), Start: start}, trains, start, end)
+}
+
+func (FlowScheduler) FleetRoutes(ctx context.Context, network *Network, start, end string, paces []int) ([][]string, error) {
+	combinations, timer, err := flowRouteCombinations(ctx, network, start, end, len(paces))
+	if err != nil {
+		return nil, err
+	}
+	return FindOptimalFleetRoute(paces, combinations, timer), nil
+}
+
+// flowEdge is an arc of the residual graph. Every arc is stored together with its reverse arc.
+type flowEdge struct {
+	to       int
+	rev      int // Index of the reverse arc in the adjacency list of to
+	capacity int
+	cost     int
+	flow     int
+}
+
+// flowGraph is a residual graph where every station is split into an "in" and an "out" node,
+// so that a capacity on the in→out arc limits how many routes may pass through the station.
+type flowGraph struct {
+	names []string
+	index map[string]int
+	adj   [][]flowEdge
+}
+
+// newFlowGraph builds the residual graph of the network. A connection costs its turns, plus
+// penalty for every turn its headway, or the turns the station it leads to is held for a train,
+// adds between two trains following each other. As routes sharing a connection share its tracks,
+// every track is an arc of its own, costing more the more routes already use the connection.
+func newFlowGraph(network *Network, start, end string, penalty int) *flowGraph {
+	g := &flowGraph{index: make(map[string]int, len(network.Stations))}
+	for i, station := range network.Stations {
+		g.names = append(g.names, station.Name)
+		g.index[station.Name] = i
+	}
+	g.adj = make([][]flowEdge, 2*len(network.Stations))
+
+	for _, station := range network.Stations {
+		if station.Name == start || station.Name == end {
+			continue
+		}
+		i := g.index[station.Name]
+		g.addEdge(g.in(i), g.out(i), station.Platforms(), 0)
+	}
+	for _, conn := range network.Connections {
+		for _, direction := range conn.Directions() {
+			from, to := g.index[direction[0]], g.index[direction[1]]
+			if penalty == 0 {
+				g.addEdge(g.out(from), g.in(to), conn.Tracks(), conn.Turns())
+				continue
+			}
+			// A station holds a train for the turns it takes to reach it
+			stationHeadway := 1
+			if direction[1] != end {
+				platforms := network.Stations[to].Platforms()
+				stationHeadway = (conn.Turns() + platforms - 1) / platforms
+			}
+			for routes := 1; routes <= conn.Tracks(); routes++ {
+				headway := max((conn.Turns()*routes+conn.Tracks()-1)/conn.Tracks(), stationHeadway)
+				g.addEdge(g.out(from), g.in(to), 1, conn.Turns()+penalty*(headway-1))
+			}
+		}
+	}
+	return g
+}
+
+func (g *flowGraph) in(station int) int  { return 2 * station }
+func (g *flowGraph) out(station int) int { return 2*station + 1 }
+
+func (g *flowGraph) addEdge(from, to, capacity, cost int) {
+	g.adj[from] = append(g.adj[from], flowEdge{to: to, rev: len(g.adj[to]), capacity: capacity, cost: cost})
+	g.adj[to] = append(g.adj[to], flowEdge{to: from, rev: len(g.adj[from]) - 1, capacity: 0, cost: -cost})
+}
+
+// augment pushes one unit of flow along the cheapest residual path from source to sink and
+// returns the stations the path enters, or nil when the sink can no longer be reached.
+// Unless reroute is set, the path only takes arcs that still have capacity left, so that the
+// routes found before are kept as they are and the path is a route of its own.
+func (g *flowGraph) augment(source, sink int, reroute bool) []string {
+	dist := make([]int, len(g.adj))
+	prevNode := make([]int, len(g.adj))
+	prevEdge := make([]int, len(g.adj))
+	inQueue := make([]bool, len(g.adj))
+	for i := range dist {
+		dist[i] = math.MaxInt
+		prevNode[i] = -1
+	}
+
+	// Shortest path with Bellman-Ford in queue form, since reverse arcs carry negative costs
+	dist[source] = 0
+	queue := []int{source}
+	inQueue[source] = true
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		inQueue[node] = false
+
+		for edgeIdx, edge := range g.adj[node] {
+			if edge.capacity-edge.flow <= 0 || (!reroute && edge.capacity == 0) {
+				continue
+			}
+			if newDist := dist[node] + edge.cost; newDist < dist[edge.to] {
+				dist[edge.to] = newDist
+				prevNode[edge.to] = node
+				prevEdge[edge.to] = edgeIdx
+				if !inQueue[edge.to] {
+					queue = append(queue, edge.to)
+					inQueue[edge.to] = true
+				}
+			}
+		}
+	}
+
+	if dist[sink] == math.MaxInt {
+		return nil
+	}
+
+	var path []string
+	for node := sink; node != source; node = prevNode[node] {
+		edge := &g.adj[prevNode[node]][prevEdge[node]]
+		edge.flow++
+		g.adj[node][edge.rev].flow--
+		if node%2 == 0 {
+			path = append(path, g.names[node/2])
+		}
+	}
+	slices.Reverse(path)
+	return path
+}
+
+// routes decomposes the current flow into station routes, each excluding the start station.
+func (g *flowGraph) routes(source, sink int) [][]string {
+	used := make([][]int, len(g.adj))
+	for node, edges := range g.adj {
+		used[node] = make([]int, len(edges))
+	}
+
+	var routes [][]string
+	for {
+		var route []string
+		node := source
+		for node != sink {
+			next := -1
+			for edgeIdx, edge := range g.adj[node] {
+				if edge.flow-used[node][edgeIdx] > 0 {
+					used[node][edgeIdx]++
+					next = edge.to
+					break
+				}
+			}
+			if next == -1 {
+				return routes
+			}
+			if next%2 == 0 {
+				route = append(route, g.names[next/2])
+			}
+			node = next
+		}
+		routes = append(routes, route)
+	}
+}
+
+// FindDisjointRoutes returns the set of routes from start to end on which the given number of
+// trains arrive soonest, according to FindOptimalRoute. The routes use every station and every
+// connection no more often than its capacity.
+// Routes exclude the start station and are sorted by duration.
+func FindDisjointRoutes(ctx context.Context, network *Network, start, end string, trains int) ([][]string, error) {
+	combinations, timer, err := flowRouteCombinations(ctx, network, start, end, trains)
+	if err != nil {
+		return nil, err
+	}
+	routes, _ := FindOptimalRoute(trains, combinations, timer)
+	return routes, nil
+}
+
+// shortestRoutes is the number of fastest routes flowRouteCombinations adds to the routes of the flows.
+const shortestRoutes = 8
+
+// flowRouteCombinations returns the route sets of the flows, followed by every combination of
+// their routes and the fastest few routes. Flows only compare total durations, so a route set
+// that lets trains wait less for each other may mix routes of different flows, or take a route
+// that no flow does because it is slightly slower than another.
+func flowRouteCombinations(ctx context.Context, network *Network, start, end string, trains int) ([][][]string, RouteTimer, error) {
+	candidates, timer, err := disjointRouteCandidates(ctx, network, start, end, trains)
+	if err != nil {
+		return nil, RouteTimer{}, err
+	}
+	fastest, err := KShortestRoutes(ctx, timer.Graph, start, end, shortestRoutes)
+	if err != nil {
+		return nil, RouteTimer{}, err
+	}
+
+	var routes [][]string
+	seen := make(map[string]bool)
+	for _, routeSet := range append([][][]string{fastest}, candidates...) {
+		for _, route := range routeSet {
+			if key := fmt.Sprint(route); !seen[key] {
+				seen[key] = true
+				routes = append(routes, route)
+			}
+		}
+	}
+	sortRoutes(routes, timer)
+
+	combinations, err := FindAllRouteCombinations(ctx, routes, end, timer)
+	if err != nil {
+		return nil, RouteTimer{}, err
+	}
+	return append(candidates, combinations...), timer, nil
+}
+
+// disjointRouteCandidates adds up to trains routes one by one and returns every distinct route
+// set found on the way, for the caller to pick from. When a connection has a headway of more
+// than a turn, the routes are searched again with a growing penalty on such connections, as
+// the shortest routes may then be the slowest for many trains.
+func disjointRouteCandidates(ctx context.Context, network *Network, start, end string, trains int) ([][][]string, RouteTimer, error) {
+	timer := RouteTimer{Graph: NewGraph(network), Start: start}
+
+	penalties := []int{0}
+	if slices.ContainsFunc(network.Connections, func(conn Connection) bool { return conn.Turns() > 1 }) {
+		for penalty := 1; penalty < trains; penalty *= 2 {
+			penalties = append(penalties, penalty)
+		}
+	}
+
+	var candidates [][][]string
+	seen := make(map[string]bool)
+	for _, penalty := range penalties {
+		for _, reroute := range []bool{true, false} {
+			routeSets, err := flowRouteSets(ctx, newFlowGraph(network, start, end, penalty), start, end, trains, reroute)
+			if err != nil {
+				return nil, RouteTimer{}, err
+			}
+			for _, routes := range routeSets {
+				sortRoutes(routes, timer)
+				if key := fmt.Sprint(routes); !seen[key] {
+					seen[key] = true
+					candidates = append(candidates, routes)
+				}
+			}
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil, RouteTimer{}, ValidatePathExistence(nil, start, end)
+	}
+	return candidates, timer, nil
+}
+
+// flowRouteSets augments the flow up to trains times and returns the routes after every augmentation.
+// Without reroute, every route is the path it was added along. Otherwise the flow is decomposed
+// anew, as an augmentation may reroute the earlier routes.
+func flowRouteSets(ctx context.Context, g *flowGraph, start, end string, trains int, reroute bool) ([][][]string, error) {
+	source := g.out(g.index[start])
+	sink := g.in(g.index[end])
+
+	var routeSets [][][]string
+	var added [][]string
+	// More routes than trains can never help, so the search stops there
+	for k := 1; k <= trains; k++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		path := g.augment(source, sink, reroute)
+		if path == nil {
+			break
+		}
+
+		if reroute {
+			routeSets = append(routeSets, g.routes(source, sink))
+		} else {
+			added = append(added, path)
+			routeSets = append(routeSets, slices.Clone(added))
+		}
+	}
+	return routeSets, nil
+}
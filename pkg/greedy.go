@@ -0,0 +1,387 @@
+package train
+
+import (
+	"container/heap"
+	"container/list"
+	"context"
+	"fmt"
+	"math"
+)
+
+type PriorityQueue []*Node
+
+type Node struct {
+	station  string
+	priority int
+	index    int
+}
+
+func (pq PriorityQueue) Len() int { return len(pq) }
+
+func (pq PriorityQueue) Less(i, j int) bool {
+	return pq[i].priority < pq[j].priority
+}
+
+func (pq PriorityQueue) Swap(i, j int) {
+	pq[i], pq[j] = pq[j], pq[i]
+	pq[i].index = i
+	pq[j].index = j
+}
+
+func (pq *PriorityQueue) Push(x interface{}) {
+	n := len(*pq)
+	node := x.(*Node)
+	node.index = n
+	*pq = append(*pq, node)
+}
+
+func (pq *PriorityQueue) Pop() interface{} {
+	old := *pq
+	n := len(old)
+	node := old[n-1]
+	old[n-1] = nil
+	node.index = -1
+	*pq = old[0 : n-1]
+	return node
+}
+
+// Heuristic function for A* (using Manhattan distance).
+func heuristic(from, to Station) int {
+	return int(math.Abs(float64(from.X-to.X)) + math.Abs(float64(from.Y-to.Y)))
+}
+
+// AStarSearch algorithm finds the shortest path using A* search algorithm.
+func AStarSearch(graph *Graph, start, goal string) ([]string, error) {
+	goalStation := graph.Stations[goal]
+
+	pq := &PriorityQueue{}
+	heap.Init(pq)
+	heap.Push(pq, &Node{station: start, priority: 0})
+
+	cameFrom := make(map[string]string)
+	costSoFar := make(map[string]int)
+	cameFrom[start] = ""
+	costSoFar[start] = 0
+
+	for pq.Len() > 0 {
+		current := heap.Pop(pq).(*Node).station
+
+		if current == goal {
+			path := []string{}
+			for current != "" {
+				path = append([]string{current}, path...)
+				current = cameFrom[current]
+			}
+			return path, nil
+		}
+
+		for _, next := range graph.AdjList[current] {
+			newCost := costSoFar[current] + graph.Weight(current, next)
+			if cost, ok := costSoFar[next]; !ok || newCost < cost {
+				costSoFar[next] = newCost
+				priority := newCost + heuristic(graph.Stations[next], goalStation)
+				heap.Push(pq, &Node{station: next, priority: priority})
+				cameFrom[next] = current
+			}
+		}
+	}
+
+	return nil, ValidatePathExistence(nil, start, goal)
+}
+
+// BFS algorithm for comparison or fallback.
+func BFS(graph *Graph, start, goal string) ([]string, error) {
+	visited := make(map[string]bool)
+	queue := list.New()
+	queue.PushBack(start)
+	visited[start] = true
+	cameFrom := make(map[string]string)
+	cameFrom[start] = ""
+
+	for queue.Len() > 0 {
+		current := queue.Remove(queue.Front()).(string)
+
+		if current == goal {
+			path := []string{}
+			for current != "" {
+				path = append([]string{current}, path...)
+				current = cameFrom[current]
+			}
+			return path, nil
+		}
+
+		for _, next := range graph.AdjList[current] {
+			if !visited[next] {
+				queue.PushBack(next)
+				visited[next] = true
+				cameFrom[next] = current
+			}
+		}
+	}
+
+	return nil, ValidatePathExistence(nil, start, goal)
+}
+
+// HybridSearch combines BFS and A* to find paths based on the number of trains.
+// BFS ignores connection weights, so weighted graphs always use A*.
+// It returns the error of ctx once ctx is done.
+func HybridSearch(ctx context.Context, graph *Graph, start, goal string, numTrains int) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if numTrains > 3 || graph.Weighted() { // Example condition to switch algorithms
+		return AStarSearch(graph, start, goal)
+	}
+	return BFS(graph, start, goal)
+}
+
+// MoveTrains moves the trains greedily one turn at a time and returns the movements of every turn.
+// A train on a connection that takes several turns is recorded when it arrives. When a train is
+// blocked it may take a detour, but never into a part of the network from which it can only get
+// back to the end station through the station it left. It fails with ErrDeadlock when trains stop
+// moving, or keep moving for more turns than any train could need, before all of them reach the end,
+// and with the error of ctx once ctx is done.
+func MoveTrains(ctx context.Context, graph *Graph, startStation, endStation string, numTrains int) ([][]Move, error) {
+	var turnMoves [][]Move
+	trains := make(map[string]string)          // Map train ID to its current station
+	previousStation := make(map[string]string) // Store previous station of trains
+	movedAway := make(map[string]bool)         // Track if a train has moved away from the start
+	transit := make(map[string]int)            // Turns left before a travelling train arrives
+	heldBack := make(map[string]bool)          // Trains that waited once for a direct connection to the end
+
+	// Initialize trains at the starting station
+	for i := 1; i <= numTrains; i++ {
+		trainID := trainName(i)
+		trains[trainID] = startStation
+		previousStation[trainID] = "" // Initially, the train has no previous station
+		movedAway[trainID] = false    // Track that the train has not moved away yet
+	}
+
+	// Station occupation map; the start and end stations hold any number of trains
+	occupiedStations := make(map[string]int)
+	full := func(station string) bool {
+		return station != startStation && station != endStation && occupiedStations[station] >= graph.Capacity(station)
+	}
+	// A train does not go back where it came from, unless no train could move on the last turn
+	idle := false
+	goesBack := func(trainID, station string) bool { return station == previousStation[trainID] && !idle }
+	// Connection usage map, by sorted station pair so that trains in both directions count
+	usedTracks := make(map[[2]string]int)
+	busy := func(track [2]string) bool { return usedTracks[track] >= graph.Tracks(track[0], track[1]) }
+
+	// Detours from a station to a neighbour that still reach the end, cached as the graph does not change
+	detours := make(map[[2]string]bool)
+	detour := func(from, to string) bool {
+		key := [2]string{from, to}
+		if ok, seen := detours[key]; seen {
+			return ok
+		}
+		detours[key] = reachesAvoiding(graph, to, endStation, from)
+		return detours[key]
+	}
+
+	// Every train travelling every connection one after the other is the most a schedule can need
+	maxWeight := 1
+	for _, weight := range graph.weights {
+		maxWeight = max(maxWeight, weight)
+	}
+	maxTurns := 100 * numTrains * (len(graph.AdjList) + 1) * maxWeight
+
+	turns := 0
+	for {
+		turns++
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if turns > maxTurns {
+			return nil, &detailedError{ErrDeadlock, fmt.Sprintf("Trains keep blocking each other after %d turns", maxTurns)}
+		}
+		turnMovement := []Move{}
+		done := true
+		inTransit := false
+
+		// Count every train where it is, or where it travels to, before any train moves, so that
+		// trains waiting at a station keep their place in it
+		occupiedStations = make(map[string]int)
+		for _, station := range trains {
+			occupiedStations[station]++
+		}
+
+		// Mark the connections of travelling trains before any train moves, so that no train
+		// enters a connection that a travelling train is still on
+		usedTracks = make(map[[2]string]int)
+		for trainID, left := range transit {
+			if left > 0 {
+				usedTracks[graph.track(previousStation[trainID], trains[trainID])]++
+			}
+		}
+
+		// Move each train
+		for i := 1; i <= numTrains; i++ {
+			trainID := trainName(i)
+			currentStation := trains[trainID]
+
+			// A travelling train keeps its destination and its connection until it arrives
+			if transit[trainID] > 0 {
+				transit[trainID]--
+				if transit[trainID] == 0 {
+					turnMovement = append(turnMovement, Move{Train: trainID, From: previousStation[trainID], To: currentStation, Duration: graph.Weight(previousStation[trainID], currentStation)})
+				} else {
+					inTransit = true
+				}
+				done = false
+				continue
+			}
+
+			if currentStation == endStation {
+				continue // If the train has reached the destination, it no longer moves
+			}
+
+			// Find path from current station to the destination
+			path, err := HybridSearch(ctx, graph, currentStation, endStation, numTrains)
+			if err != nil {
+				return nil, err
+			}
+
+			// Get the next station from the path
+			nextStation := path[1]
+			connection := graph.track(currentStation, nextStation)
+
+			// Check if the connection is already used
+			if (nextStation != endStation && full(nextStation)) || busy(connection) || goesBack(trainID, nextStation) {
+				// Attempt to find an alternative path or wait
+				foundAlternative := false
+				for _, alternativeStation := range graph.AdjList[currentStation] {
+					alternativeConnection := graph.track(currentStation, alternativeStation)
+					if !full(alternativeStation) && !busy(alternativeConnection) && !goesBack(trainID, alternativeStation) && detour(currentStation, alternativeStation) {
+						nextStation = alternativeStation
+						connection = alternativeConnection
+						foundAlternative = true
+						break
+					}
+				}
+				if !foundAlternative {
+					// If no alternative path found, the train stays at its current station
+					turnMovement = append(turnMovement, Move{Train: trainID, From: currentStation, To: currentStation})
+					continue
+				}
+			}
+
+			// Check if only one train is left that hasn't reached its destination
+			remainingTrains := 0
+			var remainingTrainID string
+			for id := range trains {
+				if trains[id] != endStation {
+					remainingTrains++
+					remainingTrainID = trainName(numTrains)
+				}
+			}
+
+			// Move the train to the next station if it's not occupied and not the previous station, except at the end station
+			if (!full(nextStation) || nextStation == endStation) && !goesBack(trainID, nextStation) {
+				// If only one train is left and a direct path is possible next time, wait one turn and let it go next time directly
+				if remainingTrains == 2 && trainID == remainingTrainID && !heldBack[trainID] && directPathPossible(graph, currentStation, endStation) {
+					turnMovement = append(turnMovement, Move{Train: trainID, From: currentStation, To: currentStation})
+					heldBack[trainID] = true
+				} else {
+					previousStation[trainID] = currentStation
+					trains[trainID] = nextStation
+					if weight := graph.Weight(currentStation, nextStation); weight > 1 {
+						transit[trainID] = weight - 1 // Recorded when it arrives
+						movedAway[trainID] = true
+						inTransit = true
+					} else {
+						// Every move is recorded, also back onto the start station, so the schedule never jumps between stations
+						turnMovement = append(turnMovement, Move{Train: trainID, From: currentStation, To: nextStation})
+						movedAway[trainID] = true // Mark the train as having moved away
+					}
+					occupiedStations[currentStation]-- // The train makes room for the next one
+					occupiedStations[nextStation]++
+					usedTracks[connection]++ // Mark the connection as used
+					done = false
+				}
+			} else {
+				// Station is occupied or it's the previous station, the train waits
+				turnMovement = append(turnMovement, Move{Train: trainID, From: currentStation, To: currentStation})
+			}
+		}
+
+		// Print movements of the current turn, but only if there are any movements
+		if len(turnMovement) > 0 {
+			// Only include movements for trains that have moved away from the starting station
+			filteredMovement := []Move{}
+			for _, move := range turnMovement {
+				if movedAway[move.Train] {
+					filteredMovement = append(filteredMovement, move)
+				}
+			}
+			if len(filteredMovement) > 0 {
+				turnMoves = append(turnMoves, filteredMovement)
+				inTransit = false
+			}
+		}
+		// Keep the turn even without arrivals while trains are on their way, so the turns stay counted
+		if inTransit {
+			turnMoves = append(turnMoves, []Move{})
+		}
+
+		// End once no train moves any more, which is an error unless all trains have reached the
+		// destination. Trains that had to wait may go back on the next turn, so only a second turn
+		// without moves means they are stuck.
+		if done {
+			if allArrived(trains, endStation) {
+				break
+			}
+			if idle {
+				return nil, &detailedError{ErrDeadlock, fmt.Sprintf("Trains block each other from turn %d on", turns-1)}
+			}
+		}
+		idle = done
+	}
+	return turnMoves, nil
+}
+
+// allArrived reports whether every train is at the end station.
+func allArrived(trains map[string]string, endStation string) bool {
+	for _, station := range trains {
+		if station != endStation {
+			return false
+		}
+	}
+	return true
+}
+
+// reachesAvoiding reports whether end can be reached from a station without passing through avoid.
+func reachesAvoiding(graph *Graph, from, end, avoid string) bool {
+	visited := map[string]bool{from: true, avoid: true}
+	queue := []string{from}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current == end {
+			return true
+		}
+		for _, next := range graph.AdjList[current] {
+			if !visited[next] {
+				visited[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return false
+}
+
+func directPathPossible(graph *Graph, currentStation, endStation string) bool {
+	// Get the list of directly connected stations from the current station using the graph's adjacency list
+	connectedStations := graph.AdjList[currentStation]
+
+	// Check if the end station is in the list of directly connected stations
+	for _, station := range connectedStations {
+		if station == endStation {
+			return true
+		}
+	}
+
+	// If not found, return false
+	return false
+}
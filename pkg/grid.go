@@ -0,0 +1,168 @@
+package train
+
+import "strings"
+
+// Grid is a character drawing of a network with every station placed by its coordinates.
+type Grid struct {
+	cells     [][]rune
+	positions map[string][2]int // Column and row of every station
+}
+
+// Bounds returns the smallest and largest coordinates of the stations.
+func Bounds(stations []Station) (minX, minY, maxX, maxY int) {
+	for i, station := range stations {
+		if i == 0 || station.X < minX {
+			minX = station.X
+		}
+		if i == 0 || station.Y < minY {
+			minY = station.Y
+		}
+		if i == 0 || station.X > maxX {
+			maxX = station.X
+		}
+		if i == 0 || station.Y > maxY {
+			maxY = station.Y
+		}
+	}
+	return minX, minY, maxX, maxY
+}
+
+// NewGrid draws the network on a grid of width×height characters. Station coordinates are
+// scaled to fill the grid, leaving room on the right for the longest station name, connections
+// are drawn with dots and station names are written next to their marker where there is room.
+func NewGrid(network *Network, width, height int) *Grid {
+	minX, minY, maxX, maxY := Bounds(network.Stations)
+	longestName := 0
+	for _, station := range network.Stations {
+		longestName = max(longestName, len([]rune(station.Name)))
+	}
+	cols := max(1, width-longestName-1)
+	rows := height
+
+	g := &Grid{
+		cells:     make([][]rune, rows),
+		positions: make(map[string][2]int, len(network.Stations)),
+	}
+	for row := range g.cells {
+		g.cells[row] = []rune(strings.Repeat(" ", width))
+	}
+
+	for _, station := range network.Stations {
+		g.positions[station.Name] = [2]int{scale(station.X, minX, maxX, cols), scale(station.Y, minY, maxY, rows)}
+	}
+	for _, conn := range network.Connections {
+		from, okFrom := g.positions[conn.From]
+		to, okTo := g.positions[conn.To]
+		if okFrom && okTo {
+			g.drawLine(from, to)
+		}
+	}
+	for _, station := range network.Stations {
+		pos := g.positions[station.Name]
+		g.Set(pos[0], pos[1], 'o')
+	}
+	for _, station := range network.Stations {
+		pos := g.positions[station.Name]
+		g.label(pos[0]+1, pos[1], station.Name)
+	}
+	return g
+}
+
+// scale maps a coordinate in [low, high] onto the cells [0, cells).
+func scale(value, low, high, cells int) int {
+	if high == low || cells <= 1 {
+		return 0
+	}
+	return (value - low) * (cells - 1) / (high - low)
+}
+
+// drawLine draws a dotted line between two cells with Bresenham's algorithm.
+func (g *Grid) drawLine(from, to [2]int) {
+	col, row := from[0], from[1]
+	dCol, dRow := abs(to[0]-col), -abs(to[1]-row)
+	stepCol, stepRow := 1, 1
+	if col > to[0] {
+		stepCol = -1
+	}
+	if row > to[1] {
+		stepRow = -1
+	}
+
+	errTerm := dCol + dRow
+	for {
+		if g.At(col, row) == ' ' {
+			g.Set(col, row, '.')
+		}
+		if col == to[0] && row == to[1] {
+			return
+		}
+		if e2 := 2 * errTerm; e2 >= dRow {
+			errTerm += dRow
+			col += stepCol
+		} else {
+			errTerm += dCol
+			row += stepRow
+		}
+	}
+}
+
+// label writes text starting at the given cell when every cell it needs, plus one after it, is free.
+func (g *Grid) label(col, row int, text string) {
+	runes := []rune(text)
+	for i := 0; i <= len(runes); i++ {
+		if c := g.At(col+i, row); c != ' ' && c != '.' && !(i == len(runes) && c == 0) {
+			return
+		}
+	}
+	for i, r := range runes {
+		g.Set(col+i, row, r)
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// Position returns the cell of a station.
+func (g *Grid) Position(station string) (col, row int, ok bool) {
+	pos, ok := g.positions[station]
+	return pos[0], pos[1], ok
+}
+
+// At returns the character of a cell, or 0 outside the grid.
+func (g *Grid) At(col, row int) rune {
+	if row < 0 || row >= len(g.cells) || col < 0 || col >= len(g.cells[row]) {
+		return 0
+	}
+	return g.cells[row][col]
+}
+
+// Set replaces the character of a cell. Cells outside the grid are ignored.
+func (g *Grid) Set(col, row int, r rune) {
+	if row < 0 || row >= len(g.cells) || col < 0 || col >= len(g.cells[row]) {
+		return
+	}
+	g.cells[row][col] = r
+}
+
+// Clone returns an independent copy of the grid.
+func (g *Grid) Clone() *Grid {
+	clone := &Grid{cells: make([][]rune, len(g.cells)), positions: g.positions}
+	for row, cells := range g.cells {
+		clone.cells[row] = append([]rune(nil), cells...)
+	}
+	return clone
+}
+
+// String returns the drawing with trailing spaces trimmed from every line.
+func (g *Grid) String() string {
+	var b strings.Builder
+	for _, cells := range g.cells {
+		b.WriteString(strings.TrimRight(string(cells), " "))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
@@ -0,0 +1,306 @@
+package train
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// DefaultGTFSScale is the number of metres one map coordinate stands for in an imported GTFS feed.
+const DefaultGTFSScale = 100
+
+const earthRadius = 6371000 // Metres
+
+// zipMagic starts every zip archive, and so every GTFS feed.
+var zipMagic = []byte("PK\x03\x04")
+
+// GTFSOptions configure how a GTFS feed becomes a network.
+type GTFSOptions struct {
+	Scale  int      // Metres per map coordinate, DefaultGTFSScale when 0
+	Routes []string // route_id of the routes to import, every route when empty
+}
+
+// isGTFS reports whether r starts with a zip archive, without consuming any of it.
+func isGTFS(r *bufio.Reader) bool {
+	magic, _ := r.Peek(len(zipMagic))
+	return bytes.Equal(magic, zipMagic)
+}
+
+// ParseGTFS reads the GTFS feed in the zip file at filePath and returns its network, like ReadGTFS.
+func ParseGTFS(filePath string, options GTFSOptions) (*Network, error) {
+	archive, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+
+	return readGTFS(&archive.Reader, options)
+}
+
+// ReadGTFS reads a GTFS feed from a zip archive of size bytes and returns its network.
+// Only stops.txt, trips.txt and stop_times.txt are read:
+//
+//   - Every stop served by an imported trip becomes a station. Stops with a parent_station are
+//     merged into the stop at the top of their parent_station chain, so the platforms and
+//     entrances of a station make one station.
+//   - Stations are named after stop_name, in lower case with every run of other characters than
+//     letters and digits replaced by "_", so that the names can be written in a text map. Stops
+//     sharing a name get their stop_id appended.
+//   - Latitudes and longitudes are projected onto a plane with the north-west corner of the
+//     network at 0,0 and one coordinate per Scale metres. Stations falling on the same point are
+//     moved east until the point is free.
+//   - Every pair of stations a trip visits one after the other, ordered by stop_sequence, becomes
+//     a two-way connection taking one turn, and is only added once whatever the direction.
+//
+// The network passes the same checks as a text map. Invalid records are reported with their line
+// and column in their file. Coordinates are only checked for the stops that become stations,
+// since other stops, such as the nodes and boarding areas of a station, may leave them empty.
+func ReadGTFS(r io.ReaderAt, size int64, options GTFSOptions) (*Network, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	return readGTFS(archive, options)
+}
+
+// readGTFSStream reads a GTFS feed that is not in a file, such as one piped in, with the
+// default options. The zip archive is read into memory first, as its index is at its end.
+func readGTFSStream(r io.Reader) (*Network, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return ReadGTFS(bytes.NewReader(data), int64(len(data)), GTFSOptions{})
+}
+
+// gtfsStop is a stop of stops.txt.
+type gtfsStop struct {
+	id, name, parent string
+	lat, lon         float64
+	invalid          error // Invalid coordinates, reported only if the stop becomes a station
+}
+
+func readGTFS(archive *zip.Reader, options GTFSOptions) (*Network, error) {
+	scale := options.Scale
+	if scale <= 0 {
+		scale = DefaultGTFSScale
+	}
+
+	var stops []gtfsStop
+	stopIndex := make(map[string]int)
+	err := readGTFSFile(archive, "stops.txt", []string{"stop_id", "stop_lat", "stop_lon"}, func(table *csvTable, record []string) error {
+		id := table.value(record, "stop_id")
+		stop := gtfsStop{id: id, name: table.value(record, "stop_name"), parent: table.value(record, "parent_station")}
+		var latErr, lonErr error
+		stop.lat, latErr = strconv.ParseFloat(table.value(record, "stop_lat"), 64)
+		stop.lon, lonErr = strconv.ParseFloat(table.value(record, "stop_lon"), 64)
+		switch {
+		case latErr != nil || stop.lat < -90 || stop.lat > 90:
+			stop.invalid = table.invalid(record, "stop coordinates in stops.txt", "stop_lat")
+		case lonErr != nil || stop.lon < -180 || stop.lon > 180:
+			stop.invalid = table.invalid(record, "stop coordinates in stops.txt", "stop_lon")
+		}
+		stopIndex[id] = len(stops)
+		stops = append(stops, stop)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	kept := make(map[string]bool)
+	err = readGTFSFile(archive, "trips.txt", []string{"trip_id", "route_id"}, func(table *csvTable, record []string) error {
+		if len(options.Routes) == 0 || slices.Contains(options.Routes, table.value(record, "route_id")) {
+			kept[table.value(record, "trip_id")] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// A call is a trip stopping at a station
+	type call struct {
+		sequence, stop int
+	}
+	calls := make(map[string][]call)
+	err = readGTFSFile(archive, "stop_times.txt", []string{"trip_id", "stop_id", "stop_sequence"}, func(table *csvTable, record []string) error {
+		trip := table.value(record, "trip_id")
+		if !kept[trip] {
+			return nil
+		}
+		sequence, err := strconv.Atoi(table.value(record, "stop_sequence"))
+		if err != nil || sequence < 0 {
+			return table.invalid(record, "stop sequence in stop_times.txt", "stop_sequence")
+		}
+		stop, ok := stopIndex[table.value(record, "stop_id")]
+		if !ok {
+			return table.invalid(record, "stop in stop_times.txt", "stop_id")
+		}
+		calls[trip] = append(calls[trip], call{sequence, rootStop(stops, stopIndex, stop)})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	trips := make([]string, 0, len(calls))
+	for trip := range calls {
+		trips = append(trips, trip)
+	}
+	sort.Strings(trips)
+
+	var served []int // Stops in the order trips first reach them
+	stationOf := make(map[int]int)
+	var pairs [][2]int
+	seen := make(map[[2]int]bool)
+	for _, trip := range trips {
+		tripCalls := calls[trip]
+		sort.SliceStable(tripCalls, func(i, j int) bool { return tripCalls[i].sequence < tripCalls[j].sequence })
+		for i, c := range tripCalls {
+			if _, ok := stationOf[c.stop]; !ok {
+				stationOf[c.stop] = len(served)
+				served = append(served, c.stop)
+			}
+			if i == 0 || tripCalls[i-1].stop == c.stop {
+				continue
+			}
+			a, b := stationOf[tripCalls[i-1].stop], stationOf[c.stop]
+			if seen[[2]int{a, b}] || seen[[2]int{b, a}] {
+				continue
+			}
+			seen[[2]int{a, b}] = true
+			pairs = append(pairs, [2]int{a, b})
+		}
+	}
+	if len(pairs) == 0 {
+		return nil, &detailedError{ErrMissingSection, "GTFS feed has no trip between two stops"}
+	}
+	for _, stop := range served {
+		if stops[stop].invalid != nil {
+			return nil, stops[stop].invalid
+		}
+	}
+
+	stations := gtfsStations(stops, served, scale)
+	connections := make([]Connection, len(pairs))
+	for i, pair := range pairs {
+		connections[i] = Connection{From: stations[pair[0]].Name, To: stations[pair[1]].Name, Weight: 1, Capacity: 1}
+	}
+	return newNetwork(stations, connections)
+}
+
+// rootStop follows the parent_station chain of a stop up to the stop without a parent, so that
+// platforms are merged into their station. A chain looping back on itself ends where it loops.
+func rootStop(stops []gtfsStop, stopIndex map[string]int, stop int) int {
+	visited := map[int]bool{stop: true}
+	for {
+		parent, ok := stopIndex[stops[stop].parent]
+		if !ok || visited[parent] {
+			return stop
+		}
+		visited[parent] = true
+		stop = parent
+	}
+}
+
+// readGTFSFile reads every record of a file of the feed that has the required columns.
+func readGTFSFile(archive *zip.Reader, name string, required []string, read func(*csvTable, []string) error) error {
+	file, err := archive.Open(name)
+	if err != nil {
+		return &detailedError{ErrMissingSection, fmt.Sprintf("GTFS feed does not contain %s", name)}
+	}
+	defer file.Close()
+
+	table, err := newCSVTable(file, strings.TrimSuffix(name, ".txt"), required...)
+	if err != nil {
+		return err
+	}
+	for {
+		record, err := table.next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if err := read(table, record); err != nil {
+			return err
+		}
+	}
+}
+
+// gtfsStations names and projects the served stops.
+func gtfsStations(stops []gtfsStop, served []int, scale int) []Station {
+	names := make(map[string]int)
+	for _, stop := range served {
+		names[stationName(stops[stop].name, stops[stop].id)]++
+	}
+
+	minLon, maxLat, sumLat := math.Inf(1), math.Inf(-1), 0.0
+	for _, stop := range served {
+		minLon = min(minLon, stops[stop].lon)
+		maxLat = max(maxLat, stops[stop].lat)
+		sumLat += stops[stop].lat
+	}
+	// An equirectangular projection around the mean latitude is accurate enough for a city
+	metres := earthRadius * math.Pi / 180
+	lonMetres := metres * math.Cos(sumLat/float64(len(served))*math.Pi/180)
+
+	stations := make([]Station, len(served))
+	taken := make(map[[2]int]bool, len(served))
+	used := make(map[string]bool, len(served))
+	for i, stop := range served {
+		name := stationName(stops[stop].name, stops[stop].id)
+		if names[name] > 1 {
+			name = name + "_" + stationName(stops[stop].id, "")
+		}
+		for base, n := name, 2; used[name]; n++ {
+			name = fmt.Sprintf("%s_%d", base, n)
+		}
+		used[name] = true
+
+		x := int(math.Round((stops[stop].lon - minLon) * lonMetres / float64(scale)))
+		y := int(math.Round((maxLat - stops[stop].lat) * metres / float64(scale)))
+		for taken[[2]int{x, y}] {
+			x++
+		}
+		taken[[2]int{x, y}] = true
+
+		stations[i] = Station{Name: name, X: x, Y: y, Capacity: 1}
+	}
+	return stations
+}
+
+// stationName turns a stop name into a station name that can be written in a text map,
+// falling back to fallback when nothing is left.
+func stationName(name, fallback string) string {
+	var b strings.Builder
+	gap := false
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if gap && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r)
+			gap = false
+			continue
+		}
+		gap = true
+	}
+	if b.Len() == 0 && fallback != "" {
+		return stationName(fallback, "")
+	}
+	if b.Len() == 0 {
+		return "stop"
+	}
+	return b.String()
+}
@@ -0,0 +1,226 @@
+package train
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// gtfsFeed zips the files of a GTFS feed in memory.
+func gtfsFeed(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	archive := zip.NewWriter(&b)
+	for name, text := range files {
+		file, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := file.Write([]byte(text)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+// gtfsStopsTxt has a station with two platforms and a boarding area without coordinates, a
+// station of which only the node is served, and two stops of the same name.
+const gtfsStopsTxt = `stop_id,stop_name,stop_lat,stop_lon,parent_station
+central,Central,0,0,
+central_1,Central Platform 1,0.0001,0,central
+central_2,Central Platform 2,0,0.0001,central
+central_2a,Central Platform 2 Boarding,,,central_2
+market,Market St.,0,0.01,
+market_node,Market Node,,,market
+park_e,Park,-0.01,0,
+park_w,Park,-0.01,-0.01,
+`
+
+const gtfsTripsTxt = `route_id,trip_id
+red,r1
+red,r2
+blue,b1
+`
+
+// gtfsStopTimesTxt runs the red line from central to market and back, out of stop_sequence order,
+// and the blue line from the west to the east stop of the park through central.
+const gtfsStopTimesTxt = `trip_id,stop_id,stop_sequence
+r1,market_node,2
+r1,central_1,1
+r2,market,1
+r2,central_2a,2
+b1,park_w,1
+b1,central_2,2
+b1,park_e,3
+`
+
+func TestReadGTFS(t *testing.T) {
+	cases := []struct {
+		name                   string
+		stopsTxt, stopTimesTxt string
+		options                GTFSOptions
+		stations               []Station
+		connections            []Connection
+	}{
+		{
+			name:         "every route",
+			stopsTxt:     gtfsStopsTxt,
+			stopTimesTxt: gtfsStopTimesTxt,
+			stations: []Station{
+				{Name: "park_park_w", X: 0, Y: 11, Capacity: 1},
+				{Name: "central", X: 11, Y: 0, Capacity: 1},
+				{Name: "park_park_e", X: 11, Y: 11, Capacity: 1},
+				{Name: "market_st", X: 22, Y: 0, Capacity: 1},
+			},
+			connections: []Connection{
+				{From: "park_park_w", To: "central", Weight: 1, Capacity: 1},
+				{From: "central", To: "park_park_e", Weight: 1, Capacity: 1},
+				{From: "central", To: "market_st", Weight: 1, Capacity: 1},
+			},
+		},
+		{
+			name:         "red route at 1 km",
+			stopsTxt:     gtfsStopsTxt,
+			stopTimesTxt: gtfsStopTimesTxt,
+			options:      GTFSOptions{Scale: 1000, Routes: []string{"red"}},
+			stations: []Station{
+				{Name: "central", X: 0, Y: 0, Capacity: 1},
+				{Name: "market_st", X: 1, Y: 0, Capacity: 1},
+			},
+			connections: []Connection{
+				{From: "central", To: "market_st", Weight: 1, Capacity: 1},
+			},
+		},
+		{
+			name:         "stations on one point",
+			stopsTxt:     "stop_id,stop_name,stop_lat,stop_lon\na,A,0,0\nb,B,0,0.0001\nc,C,0,0.0002\n",
+			stopTimesTxt: "trip_id,stop_id,stop_sequence\nr1,a,1\nr1,b,2\nr1,c,3\n",
+			stations: []Station{
+				{Name: "a", X: 0, Y: 0, Capacity: 1},
+				{Name: "b", X: 1, Y: 0, Capacity: 1},
+				{Name: "c", X: 2, Y: 0, Capacity: 1},
+			},
+			connections: []Connection{
+				{From: "a", To: "b", Weight: 1, Capacity: 1},
+				{From: "b", To: "c", Weight: 1, Capacity: 1},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			data := gtfsFeed(t, map[string]string{"stops.txt": tc.stopsTxt, "trips.txt": gtfsTripsTxt, "stop_times.txt": tc.stopTimesTxt})
+			network, err := ReadGTFS(bytes.NewReader(data), int64(len(data)), tc.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(network.Stations, tc.stations) {
+				t.Errorf("stations %+v, want %+v", network.Stations, tc.stations)
+			}
+			if !reflect.DeepEqual(network.Connections, tc.connections) {
+				t.Errorf("connections %+v, want %+v", network.Connections, tc.connections)
+			}
+		})
+	}
+}
+
+// TestParseNetworkGTFS checks that a feed is recognised wherever a map is read.
+func TestParseNetworkGTFS(t *testing.T) {
+	data := gtfsFeed(t, map[string]string{"stops.txt": gtfsStopsTxt, "trips.txt": gtfsTripsTxt, "stop_times.txt": gtfsStopTimesTxt})
+	network, err := ParseNetwork(bytes.NewReader(data), ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(network.Stations) != 4 || len(network.Connections) != 3 {
+		t.Errorf("read %d stations and %d connections, want 4 and 3", len(network.Stations), len(network.Connections))
+	}
+}
+
+func TestReadGTFSInvalid(t *testing.T) {
+	cases := []struct {
+		name      string
+		files     map[string]string // Files replacing those of a valid feed, "" leaving one out
+		reason    string            // Reason of an ErrInvalidLine, or "" for ErrMissingSection
+		line, col int
+	}{
+		{
+			name:   "served stop without coordinates",
+			files:  map[string]string{"stops.txt": "stop_id,stop_name,stop_lat,stop_lon\na,A,0,0\nb,B,,\n"},
+			reason: "stop coordinates in stops.txt", line: 3, col: 5,
+		},
+		{
+			name:   "latitude out of range",
+			files:  map[string]string{"stops.txt": "stop_id,stop_name,stop_lat,stop_lon\na,A,91,0\nb,B,0,0\n"},
+			reason: "stop coordinates in stops.txt", line: 2, col: 5,
+		},
+		{
+			name:   "longitude out of range",
+			files:  map[string]string{"stops.txt": "stop_id,stop_lon,stop_lat\na,0,0\nb,-181,0\n"},
+			reason: "stop coordinates in stops.txt", line: 3, col: 3,
+		},
+		{
+			name: "parent without coordinates",
+			files: map[string]string{
+				"stops.txt":      "stop_id,stop_name,stop_lat,stop_lon,parent_station\na,A,0,0,\nb,B,,,\nb1,B 1,0,1,b\n",
+				"stop_times.txt": "trip_id,stop_id,stop_sequence\nr1,a,1\nr1,b1,2\n",
+			},
+			reason: "stop coordinates in stops.txt", line: 3, col: 5,
+		},
+		{
+			name:   "unknown stop",
+			files:  map[string]string{"stop_times.txt": "trip_id,stop_id,stop_sequence\nr1,a,1\nr1,z,2\n"},
+			reason: "stop in stop_times.txt", line: 3, col: 4,
+		},
+		{
+			name:   "negative sequence",
+			files:  map[string]string{"stop_times.txt": "trip_id,stop_id,stop_sequence\nr1,a,1\nr1,b,-2\n"},
+			reason: "stop sequence in stop_times.txt", line: 3, col: 6,
+		},
+		{
+			name:  "no trips file",
+			files: map[string]string{"trips.txt": ""},
+		},
+		{
+			name:  "no trip between two stops",
+			files: map[string]string{"stop_times.txt": "trip_id,stop_id,stop_sequence\nr1,a,1\n"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			files := map[string]string{
+				"stops.txt":      "stop_id,stop_name,stop_lat,stop_lon\na,A,0,0\nb,B,0,0.01\n",
+				"trips.txt":      "route_id,trip_id\nred,r1\n",
+				"stop_times.txt": "trip_id,stop_id,stop_sequence\nr1,a,1\nr1,b,2\n",
+			}
+			for name, text := range tc.files {
+				if text == "" {
+					delete(files, name)
+					continue
+				}
+				files[name] = text
+			}
+			data := gtfsFeed(t, files)
+			_, err := ReadGTFS(bytes.NewReader(data), int64(len(data)), GTFSOptions{})
+
+			if tc.reason == "" {
+				if !errors.Is(err, ErrMissingSection) {
+					t.Errorf("got error %v, want %v", err, ErrMissingSection)
+				}
+				return
+			}
+			var lineErr *ErrInvalidLine
+			if !errors.As(err, &lineErr) {
+				t.Fatalf("got error %v, want an invalid %s", err, tc.reason)
+			}
+			if lineErr.Reason != tc.reason || lineErr.Line != tc.line || lineErr.Col != tc.col {
+				t.Errorf("got invalid %s at %d:%d, want invalid %s at %d:%d", lineErr.Reason, lineErr.Line, lineErr.Col, tc.reason, tc.line, tc.col)
+			}
+		})
+	}
+}
@@ -0,0 +1,533 @@
+package train
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// File names of a CSV map read by ParseNetworkDir.
+const (
+	StationsCSV    = "stations.csv"
+	ConnectionsCSV = "connections.csv"
+)
+
+// isJSON reports whether the map in r starts with a JSON object, without consuming any of it.
+func isJSON(r *bufio.Reader) bool {
+	for n := 1; ; n++ {
+		peeked, err := r.Peek(n)
+		if len(peeked) < n {
+			return false
+		}
+		switch peeked[n-1] {
+		case ' ', '\t', '\r', '\n':
+			if err != nil {
+				return false
+			}
+			continue
+		}
+		return peeked[n-1] == '{'
+	}
+}
+
+// networkDocument is the JSON form of a network read by ParseNetworkJSON.
+type networkDocument struct {
+	Stations    []stationDocument    `json:"stations"`
+	Connections []connectionDocument `json:"connections"`
+}
+
+type stationDocument struct {
+	Name     string `json:"name"`
+	X        *int   `json:"x"`
+	Y        *int   `json:"y"`
+	Capacity int    `json:"capacity"` // 0 for a single platform
+}
+
+type connectionDocument struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Weight   int    `json:"weight"`   // 0 for a single turn
+	Capacity int    `json:"capacity"` // 0 for a single track
+	OneWay   bool   `json:"one_way"`
+}
+
+// ParseNetworkJSON reads a network from a JSON document of the form
+// {"stations": [{"name", "x", "y", "capacity"}], "connections": [{"from", "to", "weight", "capacity", "one_way"}]},
+// where capacity, weight and one_way are optional. Invalid entries are reported with their
+// 1-based position in their list as the line. The network passes the same checks as a text map.
+func ParseNetworkJSON(r io.Reader) (*Network, error) {
+	r, err := decompress(r)
+	if err != nil {
+		return nil, err
+	}
+	var doc networkDocument
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+	if err := CheckSections(doc.Stations != nil, doc.Connections != nil); err != nil {
+		return nil, err
+	}
+
+	stations := make([]Station, 0, len(doc.Stations))
+	for i, entry := range doc.Stations {
+		invalid := func(reason string) error {
+			return &ErrInvalidLine{Reason: reason, Text: fmt.Sprintf("station %d", i+1), Line: i + 1, Col: 1}
+		}
+		switch {
+		case entry.Name == "":
+			return nil, invalid("station format")
+		case entry.X == nil || entry.Y == nil || *entry.X < 0 || *entry.Y < 0:
+			return nil, invalid("station coordinates")
+		case entry.Capacity < 0:
+			return nil, invalid("station capacity")
+		}
+		stations = append(stations, Station{Name: entry.Name, X: *entry.X, Y: *entry.Y, Capacity: max(entry.Capacity, 1)})
+	}
+
+	connections := make([]Connection, 0, len(doc.Connections))
+	for i, entry := range doc.Connections {
+		invalid := func(reason string) error {
+			return &ErrInvalidLine{Reason: reason, Text: fmt.Sprintf("connection %d", i+1), Line: i + 1, Col: 1}
+		}
+		switch {
+		case entry.From == "" || entry.To == "":
+			return nil, invalid("connection format")
+		case entry.Weight < 0:
+			return nil, invalid("connection weight")
+		case entry.Capacity < 0:
+			return nil, invalid("connection capacity")
+		}
+		connections = append(connections, Connection{From: entry.From, To: entry.To, Weight: max(entry.Weight, 1), Capacity: max(entry.Capacity, 1), OneWay: entry.OneWay})
+	}
+
+	return newNetwork(stations, connections)
+}
+
+// isYAML reports whether the map in r is a YAML map, without consuming any of it: its first
+// section holds "-" entries or a value on the line of its key, like "stations: []", which the
+// text format does not allow. Only the start of the map that fits in the buffer of r is looked at.
+func isYAML(r *bufio.Reader) bool {
+	peeked, _ := r.Peek(r.Size())
+	section := false
+	for _, raw := range strings.Split(string(peeked), "\n") {
+		line, _ := cleanLine(raw)
+		switch {
+		case line == "":
+			continue
+		case line == "---":
+			return !section // Document start marker
+		case section:
+			return line == "-" || strings.HasPrefix(line, "- ")
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || (key != "stations" && key != "connections") {
+			return false
+		}
+		if strings.TrimSpace(value) != "" {
+			return true
+		}
+		section = true
+	}
+	return false
+}
+
+// yamlEntry is a station or connection of a YAML map: the line of its "-" and its keys.
+type yamlEntry struct {
+	line, col int
+	text      string
+	values    map[string]yamlValue
+}
+
+// yamlValue is a scalar of a YAML map with the line, the text of the line and the 1-based column it
+// was read from.
+type yamlValue struct {
+	text string
+	line int
+	raw  string
+	col  int
+}
+
+// ParseNetworkYAML reads a network from a YAML map with the fields of the JSON form read by
+// ParseNetworkJSON, written in block style:
+//
+//	stations:
+//	  - name: waterloo
+//	    x: 3
+//	    y: 1
+//	connections:
+//	  - from: waterloo
+//	    to: victoria
+//	    weight: 2
+//
+// capacity, weight and one_way (true or false) are optional, other keys are ignored and values may
+// be quoted. Flow style is only accepted for an empty list, "[]". Invalid entries are reported
+// with the line and column of the invalid value. The network passes the same checks as a text map.
+func ParseNetworkYAML(r io.Reader) (*Network, error) {
+	r, err := decompress(r)
+	if err != nil {
+		return nil, err
+	}
+
+	sections := map[string][]*yamlEntry{}
+	found := map[string]bool{}
+	section := ""
+	var entry *yamlEntry
+	var options ParseOptions // Lines up to DefaultMaxLineLength, like a text map
+	scanner := options.lineScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		raw := scanner.Text()
+		line, col := cleanYAMLLine(raw)
+		if line == "" || line == "---" {
+			continue
+		}
+		invalid := func(reason string, col int) error {
+			return &ErrInvalidLine{Reason: reason, Text: strings.TrimSpace(raw), Line: lineNumber, Col: col}
+		}
+
+		if col == 1 && !strings.HasPrefix(line, "-") {
+			key, value, ok := strings.Cut(line, ":")
+			if !ok {
+				return nil, invalid("map format", col)
+			}
+			section, entry = "", nil
+			if key != "stations" && key != "connections" {
+				continue // Unknown key, skipped with everything nested in it
+			}
+			if found[key] {
+				return nil, invalid("map format", col)
+			}
+			found[key] = true
+			switch value := strings.TrimSpace(value); value {
+			case "":
+				section = key
+			case "[]":
+			default:
+				return nil, invalid("map format", col+len(line)-len(value))
+			}
+			continue
+		}
+		if section == "" {
+			continue
+		}
+
+		reason := strings.TrimSuffix(section, "s") + " format"
+		if rest, ok := strings.CutPrefix(line, "-"); ok && (rest == "" || rest[0] == ' ' || rest[0] == '\t') {
+			entry = &yamlEntry{line: lineNumber, col: col, text: strings.TrimSpace(raw), values: map[string]yamlValue{}}
+			sections[section] = append(sections[section], entry)
+			trimmed := strings.TrimLeft(rest, " \t")
+			if trimmed == "" {
+				continue
+			}
+			line, col = trimmed, col+len(line)-len(trimmed)
+		} else if entry == nil {
+			return nil, invalid(reason, col)
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || strings.TrimSpace(key) == "" || (value != "" && value[0] != ' ' && value[0] != '\t') {
+			return nil, invalid(reason, col)
+		}
+		trimmed := strings.TrimLeft(value, " \t")
+		valueCol := col + len(line) - len(trimmed)
+		text, err := unquoteYAML(trimmed)
+		if err != nil {
+			return nil, invalid(reason, valueCol)
+		}
+		entry.values[strings.TrimSpace(key)] = yamlValue{text: text, line: lineNumber, raw: strings.TrimSpace(raw), col: valueCol}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, options.scanError(err, lineNumber)
+	}
+	if err := CheckSections(found["stations"], found["connections"]); err != nil {
+		return nil, err
+	}
+
+	stations := make([]Station, 0, len(sections["stations"]))
+	for _, entry := range sections["stations"] {
+		name := entry.values["name"].text
+		x, xErr := strconv.Atoi(entry.values["x"].text)
+		y, yErr := strconv.Atoi(entry.values["y"].text)
+		capacity, capacityErr := entry.count("capacity")
+		switch {
+		case name == "":
+			return nil, entry.invalid("station format", "name")
+		case xErr != nil || x < 0:
+			return nil, entry.invalid("station coordinates", "x")
+		case yErr != nil || y < 0:
+			return nil, entry.invalid("station coordinates", "y")
+		case capacityErr != nil:
+			return nil, entry.invalid("station capacity", "capacity")
+		}
+		stations = append(stations, Station{Name: name, X: x, Y: y, Capacity: capacity})
+	}
+
+	connections := make([]Connection, 0, len(sections["connections"]))
+	for _, entry := range sections["connections"] {
+		from, to := entry.values["from"].text, entry.values["to"].text
+		weight, weightErr := entry.count("weight")
+		capacity, capacityErr := entry.count("capacity")
+		oneWay, oneWayErr := false, error(nil)
+		if value := entry.values["one_way"].text; value != "" {
+			oneWay, oneWayErr = strconv.ParseBool(value)
+		}
+		switch {
+		case from == "":
+			return nil, entry.invalid("connection format", "from")
+		case to == "":
+			return nil, entry.invalid("connection format", "to")
+		case weightErr != nil:
+			return nil, entry.invalid("connection weight", "weight")
+		case capacityErr != nil:
+			return nil, entry.invalid("connection capacity", "capacity")
+		case oneWayErr != nil:
+			return nil, entry.invalid("connection direction", "one_way")
+		}
+		connections = append(connections, Connection{From: from, To: to, Weight: weight, Capacity: capacity, OneWay: oneWay})
+	}
+
+	return newNetwork(stations, connections)
+}
+
+// cleanYAMLLine strips the comment and the surrounding spaces from a raw YAML line like cleanLine,
+// but only takes a "#" at the start of the line or after a space, and outside a quoted value, for a
+// comment, so that values may contain it.
+func cleanYAMLLine(raw string) (string, int) {
+	var quote byte
+	for i := 0; i < len(raw); i++ {
+		switch c := raw[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case (c == '"' || c == '\'') && (i == 0 || raw[i-1] == ' ' || raw[i-1] == '\t'):
+			quote = c
+		case c == '#' && (i == 0 || raw[i-1] == ' ' || raw[i-1] == '\t'):
+			raw = raw[:i]
+		}
+	}
+	trimmed := strings.TrimLeft(raw, " \t")
+	col := len(raw) - len(trimmed) + 1
+	return strings.TrimRight(trimmed, " \t\r"), col
+}
+
+// unquoteYAML returns a plain, single-quoted or double-quoted YAML scalar without its quotes.
+func unquoteYAML(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, `"`):
+		return strconv.Unquote(value)
+	case strings.HasPrefix(value, "'"):
+		if len(value) < 2 || !strings.HasSuffix(value, "'") {
+			return "", fmt.Errorf("unterminated quote")
+		}
+		inner := value[1 : len(value)-1]
+		if strings.Contains(strings.ReplaceAll(inner, "''", ""), "'") {
+			return "", fmt.Errorf("unescaped quote")
+		}
+		return strings.ReplaceAll(inner, "''", "'"), nil
+	}
+	return value, nil
+}
+
+// count parses an optional positive count key, which is 1 when left out.
+func (e *yamlEntry) count(key string) (int, error) {
+	value := e.values[key].text
+	if value == "" {
+		return 1, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err == nil && n < 1 {
+		err = fmt.Errorf("%s must be positive", key)
+	}
+	return n, err
+}
+
+// invalid reports the entry as invalid, pointing at the value of key, or at the "-" of the entry
+// when the key is missing.
+func (e *yamlEntry) invalid(reason, key string) error {
+	if value, ok := e.values[key]; ok {
+		return &ErrInvalidLine{Reason: reason, Text: value.raw, Line: value.line, Col: value.col}
+	}
+	return &ErrInvalidLine{Reason: reason, Text: e.text, Line: e.line, Col: e.col}
+}
+
+// ParseNetworkDir reads a CSV map from the StationsCSV and ConnectionsCSV files in dir.
+func ParseNetworkDir(dir string) (*Network, error) {
+	stations, err := os.Open(filepath.Join(dir, StationsCSV))
+	if err != nil {
+		return nil, err
+	}
+	defer stations.Close()
+
+	connections, err := os.Open(filepath.Join(dir, ConnectionsCSV))
+	if err != nil {
+		return nil, err
+	}
+	defer connections.Close()
+
+	return ParseNetworkCSV(stations, connections)
+}
+
+// ParseNetworkCSV reads a network from two CSV files whose first record names the columns:
+// the stations with "name", "x", "y" and optionally "capacity", and the connections with "from",
+// "to" and optionally "weight", "capacity" and "one_way" (true or false). Columns may come in any
+// order and other columns are ignored. Invalid records are reported with their line and column.
+// The network passes the same checks as a text map.
+func ParseNetworkCSV(stations, connections io.Reader) (*Network, error) {
+	stationTable, err := newCSVTable(stations, "stations", "name", "x", "y")
+	if err != nil {
+		return nil, err
+	}
+	var parsedStations []Station
+	for {
+		record, err := stationTable.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		name, nameCol := stationTable.field(record, "name")
+		x, xErr := strconv.Atoi(stationTable.value(record, "x"))
+		y, yErr := strconv.Atoi(stationTable.value(record, "y"))
+		capacity, capacityErr := stationTable.count(record, "capacity")
+		switch {
+		case name == "":
+			return nil, stationTable.invalid(record, "station format", nameCol)
+		case xErr != nil || x < 0:
+			return nil, stationTable.invalid(record, "station coordinates", "x")
+		case yErr != nil || y < 0:
+			return nil, stationTable.invalid(record, "station coordinates", "y")
+		case capacityErr != nil:
+			return nil, stationTable.invalid(record, "station capacity", "capacity")
+		}
+		parsedStations = append(parsedStations, Station{Name: name, X: x, Y: y, Capacity: capacity})
+	}
+
+	connectionTable, err := newCSVTable(connections, "connections", "from", "to")
+	if err != nil {
+		return nil, err
+	}
+	var parsedConnections []Connection
+	for {
+		record, err := connectionTable.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		from, fromCol := connectionTable.field(record, "from")
+		to, toCol := connectionTable.field(record, "to")
+		weight, weightErr := connectionTable.count(record, "weight")
+		capacity, capacityErr := connectionTable.count(record, "capacity")
+		oneWay, oneWayErr := false, error(nil)
+		if value := connectionTable.value(record, "one_way"); value != "" {
+			oneWay, oneWayErr = strconv.ParseBool(value)
+		}
+		switch {
+		case from == "":
+			return nil, connectionTable.invalid(record, "connection format", fromCol)
+		case to == "":
+			return nil, connectionTable.invalid(record, "connection format", toCol)
+		case weightErr != nil:
+			return nil, connectionTable.invalid(record, "connection weight", "weight")
+		case capacityErr != nil:
+			return nil, connectionTable.invalid(record, "connection capacity", "capacity")
+		case oneWayErr != nil:
+			return nil, connectionTable.invalid(record, "connection direction", "one_way")
+		}
+		parsedConnections = append(parsedConnections, Connection{From: from, To: to, Weight: weight, Capacity: capacity, OneWay: oneWay})
+	}
+
+	return newNetwork(parsedStations, parsedConnections)
+}
+
+// csvTable reads the records of a CSV file whose first record names the columns.
+type csvTable struct {
+	reader  *csv.Reader
+	columns map[string]int
+}
+
+// newCSVTable reads the header of a CSV file and checks that it has the required columns.
+// kind names the file in errors.
+func newCSVTable(r io.Reader, kind string, required ...string) (*csvTable, error) {
+	r, err := decompress(r)
+	if err != nil {
+		return nil, err
+	}
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, &detailedError{ErrMissingSection, fmt.Sprintf("%s CSV is empty", kind)}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff") // Byte order mark left by spreadsheets
+		}
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range required {
+		if _, ok := columns[name]; !ok {
+			return nil, &detailedError{ErrMissingSection, fmt.Sprintf("%s CSV does not have a '%s' column", kind, name)}
+		}
+	}
+	return &csvTable{reader: reader, columns: columns}, nil
+}
+
+// next returns the next record, or io.EOF after the last one.
+func (t *csvTable) next() ([]string, error) {
+	return t.reader.Read()
+}
+
+// value returns the trimmed value of a column, or "" when the table does not have the column.
+func (t *csvTable) value(record []string, column string) string {
+	value, _ := t.field(record, column)
+	return value
+}
+
+// field returns the trimmed value of a column together with the column name, for invalid.
+func (t *csvTable) field(record []string, column string) (string, string) {
+	if i, ok := t.columns[column]; ok {
+		return strings.TrimSpace(record[i]), column
+	}
+	return "", column
+}
+
+// count parses an optional positive count column, which is 1 when left empty.
+func (t *csvTable) count(record []string, column string) (int, error) {
+	value := t.value(record, column)
+	if value == "" {
+		return 1, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err == nil && n < 1 {
+		err = fmt.Errorf("%s must be positive", column)
+	}
+	return n, err
+}
+
+// invalid reports the record just read as invalid, pointing at the given column.
+func (t *csvTable) invalid(record []string, reason, column string) error {
+	line, col := t.reader.FieldPos(0)
+	if i, ok := t.columns[column]; ok {
+		line, col = t.reader.FieldPos(i)
+	}
+	return &ErrInvalidLine{Reason: reason, Text: strings.Join(record, ","), Line: line, Col: col}
+}
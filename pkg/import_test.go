@@ -0,0 +1,229 @@
+package train
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// importedMap is the text form of the maps the import tests read in the other formats.
+const importedMap = `stations:
+waterloo,3,1
+victoria,6,7,cap=2
+st_pancras,1,4
+
+connections:
+waterloo-victoria,2
+victoria->st_pancras,cap=2
+waterloo-st_pancras
+`
+
+const importedJSON = `{
+  "stations": [
+    {"name": "waterloo", "x": 3, "y": 1},
+    {"name": "victoria", "x": 6, "y": 7, "capacity": 2},
+    {"name": "st_pancras", "x": 1, "y": 4}
+  ],
+  "connections": [
+    {"from": "waterloo", "to": "victoria", "weight": 2},
+    {"from": "victoria", "to": "st_pancras", "capacity": 2, "one_way": true},
+    {"from": "waterloo", "to": "st_pancras"}
+  ]
+}`
+
+const importedYAML = `# London
+stations:
+  - name: waterloo
+    x: 3
+    y: 1
+  - name: "victoria" # Quoted
+    x: 6
+    y: 7
+    capacity: 2
+  - name: 'st_pancras'
+    x: 1
+    y: 4
+connections:
+  - from: waterloo
+    to: victoria
+    weight: 2
+  - from: victoria
+    to: st_pancras
+    capacity: 2
+    one_way: true
+  -
+    from: waterloo
+    to: st_pancras
+`
+
+const importedStationsCSV = `name,x,y,capacity
+waterloo,3,1,
+victoria,6,7,2
+st_pancras,1,4,
+`
+
+const importedConnectionsCSV = `to,from,weight,capacity,one_way,line
+victoria,waterloo,2,,,jubilee
+st_pancras,victoria,,2,true,victoria
+st_pancras,waterloo,,,,northern
+`
+
+func TestImportFormats(t *testing.T) {
+	want := parseMap(t, importedMap)
+	cases := []struct {
+		name  string
+		parse func() (*Network, error)
+	}{
+		{"json", func() (*Network, error) { return ParseNetwork(strings.NewReader(importedJSON), ParseOptions{}) }},
+		{"yaml", func() (*Network, error) { return ParseNetwork(strings.NewReader(importedYAML), ParseOptions{}) }},
+		{"csv", func() (*Network, error) {
+			return ParseNetworkCSV(strings.NewReader(importedStationsCSV), strings.NewReader(importedConnectionsCSV))
+		}},
+		{"csv with byte order mark", func() (*Network, error) {
+			return ParseNetworkCSV(strings.NewReader("\ufeff"+importedStationsCSV), strings.NewReader(importedConnectionsCSV))
+		}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			network, err := tc.parse()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(network, want) {
+				t.Fatalf("imported %+v, want %+v", network, want)
+			}
+
+			// The network written as a text map reads back the same
+			var b bytes.Buffer
+			if err := WriteNetwork(&b, network); err != nil {
+				t.Fatal(err)
+			}
+			written, err := ParseNetwork(&b, ParseOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(written, want) {
+				t.Errorf("written map reads back as %+v, want %+v", written, want)
+			}
+		})
+	}
+}
+
+func TestImportMalformed(t *testing.T) {
+	jsonMap := func(stations, connections string) func() (*Network, error) {
+		return func() (*Network, error) {
+			text := `{"stations": [` + stations + `], "connections": [` + connections + `]}`
+			return ParseNetworkJSON(strings.NewReader(text))
+		}
+	}
+	yamlMap := func(text string) func() (*Network, error) {
+		return func() (*Network, error) { return ParseNetworkYAML(strings.NewReader(text)) }
+	}
+	csvMap := func(stations, connections string) func() (*Network, error) {
+		return func() (*Network, error) {
+			return ParseNetworkCSV(strings.NewReader(stations), strings.NewReader(connections))
+		}
+	}
+	const station = `{"name": "a", "x": 0, "y": 0}`
+	const stationsCSV = "name,x,y\na,0,0\nb,1,0\n"
+
+	cases := []struct {
+		name      string
+		parse     func() (*Network, error)
+		reason    string
+		line, col int
+	}{
+		{"json/no name", jsonMap(station+`, {"x": 1, "y": 1}`, ""), "station format", 2, 1},
+		{"json/no y", jsonMap(`{"name": "a", "x": 1}`, ""), "station coordinates", 1, 1},
+		{"json/negative x", jsonMap(`{"name": "a", "x": -1, "y": 1}`, ""), "station coordinates", 1, 1},
+		{"json/negative capacity", jsonMap(`{"name": "a", "x": 1, "y": 1, "capacity": -2}`, ""), "station capacity", 1, 1},
+		{"json/no to", jsonMap(station, `{"from": "a", "to": "a"}, {"from": "a"}`), "connection format", 2, 1},
+		{"json/negative weight", jsonMap(station, `{"from": "a", "to": "b", "weight": -1}`), "connection weight", 1, 1},
+
+		{"yaml/no name", yamlMap("stations:\n  - x: 1\n    y: 1\nconnections: []\n"), "station format", 2, 3},
+		{"yaml/text x", yamlMap("stations:\n  - name: a\n    x: one\n    y: 1\nconnections: []\n"), "station coordinates", 3, 8},
+		{"yaml/zero capacity", yamlMap("stations:\n  - name: a\n    x: 1\n    y: 1\n    capacity: 0\nconnections: []\n"), "station capacity", 5, 15},
+		{"yaml/direction", yamlMap("stations: []\nconnections:\n- from: a\n  to: b\n  one_way: maybe\n"), "connection direction", 5, 12},
+		{"yaml/unterminated quote", yamlMap("stations:\n  - name: 'a\n"), "station format", 2, 11},
+		{"yaml/no dash", yamlMap("stations:\n  name: a\n"), "station format", 2, 3},
+		{"yaml/repeated section", yamlMap("stations: []\nstations: []\n"), "map format", 2, 1},
+		{"yaml/line too long", yamlMap("stations:\n  - name: a # " + strings.Repeat("-", DefaultMaxLineLength) + "\n"), "line length", 2, 1},
+
+		{"csv/text y", csvMap("name,x,y\na,0,zero\n", "from,to\n"), "station coordinates", 2, 5},
+		{"csv/empty name", csvMap("name,x,y\na,0,0\n ,1,1\n", "from,to\n"), "station format", 3, 2},
+		{"csv/zero capacity", csvMap("y,x,name,capacity\n0,0,a,0\n", "from,to\n"), "station capacity", 2, 7},
+		{"csv/empty to", csvMap(stationsCSV, "from,to\na,b\nb,\n"), "connection format", 3, 3},
+		{"csv/text weight", csvMap(stationsCSV, "from,to,weight\na,b,two\n"), "connection weight", 2, 5},
+		{"csv/direction", csvMap(stationsCSV, "from,to,one_way\na,b,maybe\n"), "connection direction", 2, 5},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.parse()
+			var lineErr *ErrInvalidLine
+			if !errors.As(err, &lineErr) {
+				t.Fatalf("got error %v, want an invalid %s", err, tc.reason)
+			}
+			if lineErr.Reason != tc.reason || lineErr.Line != tc.line || lineErr.Col != tc.col {
+				t.Errorf("got invalid %s at %d:%d, want invalid %s at %d:%d", lineErr.Reason, lineErr.Line, lineErr.Col, tc.reason, tc.line, tc.col)
+			}
+		})
+	}
+}
+
+func TestImportMissingSections(t *testing.T) {
+	cases := []struct {
+		name  string
+		parse func() (*Network, error)
+	}{
+		{"json/no connections", func() (*Network, error) { return ParseNetworkJSON(strings.NewReader(`{"stations": []}`)) }},
+		{"yaml/no stations", func() (*Network, error) { return ParseNetworkYAML(strings.NewReader("connections: []\n")) }},
+		{"csv/empty stations", func() (*Network, error) {
+			return ParseNetworkCSV(strings.NewReader(""), strings.NewReader("from,to\n"))
+		}},
+		{"csv/no y column", func() (*Network, error) {
+			return ParseNetworkCSV(strings.NewReader("name,x\na,0\n"), strings.NewReader("from,to\n"))
+		}},
+		{"csv/no to column", func() (*Network, error) {
+			return ParseNetworkCSV(strings.NewReader("name,x,y\n"), strings.NewReader("from,destination\n"))
+		}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := tc.parse(); !errors.Is(err, ErrMissingSection) {
+				t.Errorf("got error %v, want %v", err, ErrMissingSection)
+			}
+		})
+	}
+}
+
+// TestImportChecks checks that imported maps run the same checks as a text map.
+func TestImportChecks(t *testing.T) {
+	cases := []struct {
+		name  string
+		parse func() (*Network, error)
+		want  any
+	}{
+		{"json/duplicate name", func() (*Network, error) {
+			return ParseNetworkJSON(strings.NewReader(`{"stations": [{"name": "a", "x": 0, "y": 0}, {"name": "a", "x": 1, "y": 0}], "connections": []}`))
+		}, new(*ErrDuplicateStation)},
+		{"yaml/duplicate coordinates", func() (*Network, error) {
+			return ParseNetworkYAML(strings.NewReader("stations:\n- name: a\n  x: 0\n  y: 0\n- name: b\n  x: 0\n  y: 0\nconnections: []\n"))
+		}, new(*ErrDuplicateCoordinates)},
+		{"csv/duplicate connection", func() (*Network, error) {
+			return ParseNetworkCSV(strings.NewReader("name,x,y\na,0,0\nb,1,0\n"), strings.NewReader("from,to\na,b\nb,a\n"))
+		}, new(*ErrDuplicateConnection)},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := tc.parse(); !errors.As(err, tc.want) {
+				t.Errorf("got error %v, want %T", err, tc.want)
+			}
+		})
+	}
+}
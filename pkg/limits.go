@@ -0,0 +1,112 @@
+package train
+
+import (
+	"fmt"
+	"math"
+)
+
+// Limits decide which networks are planned at all and which scheduler AutoScheduler runs for them.
+// A zero field takes its value from DefaultLimits.
+type Limits struct {
+	MaxStations   int // Maps with more stations are rejected
+	FlowThreshold int // Stations plus connections above which AutoScheduler uses the flow scheduler
+	MaxRoutes     int // Estimated routes above which AutoScheduler uses the flow scheduler
+}
+
+// DefaultLimits are the limits used when none are configured. The exhaustive scheduler
+// needs about a second for 2^12 estimated routes, such as on a 4x5 grid, but minutes on a
+// 5x5 grid with 2^16, so larger networks are left to the flow scheduler.
+var DefaultLimits = Limits{
+	MaxStations:   10000,
+	FlowThreshold: FlowThreshold,
+	MaxRoutes:     1 << 12,
+}
+
+// withDefaults fills the zero fields from DefaultLimits.
+func (l Limits) withDefaults() Limits {
+	if l.MaxStations == 0 {
+		l.MaxStations = DefaultLimits.MaxStations
+	}
+	if l.FlowThreshold == 0 {
+		l.FlowThreshold = DefaultLimits.FlowThreshold
+	}
+	if l.MaxRoutes == 0 {
+		l.MaxRoutes = DefaultLimits.MaxRoutes
+	}
+	return l
+}
+
+// NetworkSize measures a network for choosing how to plan it.
+type NetworkSize struct {
+	Stations    int
+	Connections int
+	Cycles      int // Independent cycles: connections minus stations plus connected parts
+}
+
+// MeasureNetwork counts the stations, connections and independent cycles of a network.
+func MeasureNetwork(network *Network) NetworkSize {
+	parent := make(map[string]string, len(network.Stations))
+	var find func(string) string
+	find = func(name string) string {
+		if parent[name] != name {
+			parent[name] = find(parent[name])
+		}
+		return parent[name]
+	}
+
+	for _, station := range network.Stations {
+		parent[station.Name] = station.Name
+	}
+	parts := len(network.Stations)
+	for _, conn := range network.Connections {
+		if _, ok := parent[conn.From]; !ok {
+			continue
+		}
+		if _, ok := parent[conn.To]; !ok {
+			continue
+		}
+		if a, b := find(conn.From), find(conn.To); a != b {
+			parent[a] = b
+			parts--
+		}
+	}
+
+	return NetworkSize{
+		Stations:    len(network.Stations),
+		Connections: len(network.Connections),
+		Cycles:      max(0, len(network.Connections)-len(network.Stations)+parts),
+	}
+}
+
+// RouteEstimate estimates how many routes the exhaustive scheduler enumerates between two
+// stations. Every independent cycle can at most double the routes, so the estimate is
+// 2^Cycles, capped at the largest int.
+func (s NetworkSize) RouteEstimate() int {
+	if s.Cycles >= 62 {
+		return math.MaxInt
+	}
+	return 1 << s.Cycles
+}
+
+// Check rejects networks with more stations than the limit.
+func (l Limits) Check(size NetworkSize) error {
+	l = l.withDefaults()
+	if size.Stations > l.MaxStations {
+		return &detailedError{ErrTooManyStations, fmt.Sprintf("Map contains %d stations, more than the limit of %d", size.Stations, l.MaxStations)}
+	}
+	return nil
+}
+
+// Choose returns the scheduler AutoScheduler runs for a network of the given size
+// and the reason for the choice.
+func (l Limits) Choose(size NetworkSize) (string, string) {
+	l = l.withDefaults()
+	switch {
+	case size.Stations+size.Connections > l.FlowThreshold:
+		return "flow", fmt.Sprintf("%d stations and connections, more than %d", size.Stations+size.Connections, l.FlowThreshold)
+	case size.RouteEstimate() > l.MaxRoutes:
+		return "flow", fmt.Sprintf("about 2^%d routes to enumerate, more than %d", size.Cycles, l.MaxRoutes)
+	default:
+		return "exhaustive", fmt.Sprintf("%d stations and connections and at most %d routes to enumerate", size.Stations+size.Connections, size.RouteEstimate())
+	}
+}
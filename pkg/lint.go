@@ -0,0 +1,370 @@
+package train
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Severity tells whether a diagnostic makes the map unusable.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic is a single problem found in a map file.
+type Diagnostic struct {
+	File     string
+	Line     int
+	Col      int
+	Severity Severity
+	Code     string // Stable identifier of the check, e.g. "duplicate-station"
+	Message  string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s: %s [%s]", d.File, d.Line, d.Col, d.Severity, d.Message, d.Code)
+}
+
+// lintStation is a parsed station together with where its name was found.
+type lintStation struct {
+	Station
+	line int
+	col  int
+}
+
+// lintConnection is a parsed connection together with where its station names were found.
+type lintConnection struct {
+	Connection
+	line    int
+	fromCol int
+	toCol   int
+}
+
+// linter collects the diagnostics of one map file.
+type linter struct {
+	file        string
+	diagnostics []Diagnostic
+}
+
+func (l *linter) report(line, col int, severity Severity, code, format string, args ...any) {
+	l.diagnostics = append(l.diagnostics, Diagnostic{
+		File:     l.file,
+		Line:     line,
+		Col:      col,
+		Severity: severity,
+		Code:     code,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// LintNetworkMap checks a map file and reports every problem it finds instead of stopping at the
+// first one. The returned error is only set when the file cannot be read.
+func LintNetworkMap(filePath string) ([]Diagnostic, error) {
+	return LintNetworkMapWithOptions(filePath, ParseOptions{})
+}
+
+// LintNetworkMapWithOptions checks a map file like LintNetworkMap, within the limits of options
+// on its lines.
+func LintNetworkMapWithOptions(filePath string, options ParseOptions) ([]Diagnostic, error) {
+	if info, err := os.Stat(filePath); err == nil && info.IsDir() {
+		network, err := ParseNetworkDir(filePath)
+		return lintImported(filePath, network, err)
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return LintNetwork(file, filePath, options)
+}
+
+// LintNetwork checks a map read from r like LintNetworkMap, naming it name in the diagnostics.
+// The map may be gzip compressed. A line or a map beyond the limits of the options is reported
+// and ends the check. JSON and YAML maps and GTFS feeds are imported and checked with lintImported.
+func LintNetwork(r io.Reader, name string, options ParseOptions) ([]Diagnostic, error) {
+	r, err := decompress(r)
+	if err != nil {
+		return nil, err
+	}
+	buffered := bufio.NewReader(r)
+	switch {
+	case isJSON(buffered):
+		network, err := ParseNetworkJSON(buffered)
+		return lintImported(name, network, err)
+	case isYAML(buffered):
+		network, err := ParseNetworkYAML(buffered)
+		return lintImported(name, network, err)
+	case isGTFS(buffered):
+		network, err := readGTFSStream(buffered)
+		return lintImported(name, network, err)
+	}
+	r = buffered
+
+	l := &linter{file: name}
+	var stations []lintStation
+	var connections []lintConnection
+	mode := ""
+	stationsSectionFound := false
+	connectionsSectionFound := false
+	lineNumber := 0
+
+	scanner := options.lineScanner(r)
+	for scanner.Scan() {
+		lineNumber++
+		if options.MaxLines > 0 && lineNumber > options.MaxLines {
+			l.report(lineNumber, 1, SeverityError, "too-many-lines", "map has more than %d lines; the rest is not checked", options.MaxLines)
+			break
+		}
+		line, col := cleanLine(scanner.Text())
+
+		if len(line) == 0 {
+			continue
+		}
+
+		switch {
+		case line == "stations:":
+			if stationsSectionFound {
+				l.report(lineNumber, col, SeverityWarning, "duplicate-section", "'stations:' section is declared twice")
+			}
+			mode = "stations"
+			stationsSectionFound = true
+		case line == "connections:":
+			if connectionsSectionFound {
+				l.report(lineNumber, col, SeverityWarning, "duplicate-section", "'connections:' section is declared twice")
+			}
+			mode = "connections"
+			connectionsSectionFound = true
+		case mode == "stations":
+			station, fields, lineErr := parseStationLine(line, col)
+			if lineErr != nil {
+				code := "syntax"
+				switch lineErr.Reason {
+				case "station coordinates":
+					code = "bad-coordinates"
+				case "station capacity":
+					code = "bad-capacity"
+				}
+				l.report(lineNumber, lineErr.Col, SeverityError, code, "invalid %s: %s", lineErr.Reason, line)
+				continue
+			}
+			stations = append(stations, lintStation{Station: station, line: lineNumber, col: fields[0].col})
+		case mode == "connections":
+			connection, fields, lineErr := parseConnectionLine(line, col)
+			if lineErr != nil {
+				code := "syntax"
+				switch lineErr.Reason {
+				case "connection weight":
+					code = "bad-weight"
+				case "connection capacity":
+					code = "bad-capacity"
+				}
+				l.report(lineNumber, lineErr.Col, SeverityError, code, "invalid %s: %s", lineErr.Reason, line)
+				continue
+			}
+			connections = append(connections, lintConnection{Connection: connection, line: lineNumber, fromCol: fields[0].col, toCol: fields[1].col})
+		default:
+			l.report(lineNumber, col, SeverityError, "syntax", "line outside of the 'stations:' and 'connections:' sections: %s", line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		var lineErr *ErrInvalidLine
+		if !errors.As(options.scanError(err, lineNumber), &lineErr) {
+			return nil, err
+		}
+		l.report(lineErr.Line, lineErr.Col, SeverityError, "line-too-long", "line is %s; the rest of the map is not checked", lineErr.Text)
+	}
+
+	if !stationsSectionFound {
+		l.report(1, 1, SeverityError, "missing-section", "map does not contain a 'stations:' section")
+	}
+	if !connectionsSectionFound {
+		l.report(1, 1, SeverityError, "missing-section", "map does not contain a 'connections:' section")
+	}
+	if limit := DefaultLimits.MaxStations; len(stations) > limit {
+		l.report(stations[limit].line, stations[limit].col, SeverityError, "too-many-stations", "map contains more than %d stations", limit)
+	}
+
+	l.checkStations(stations)
+	l.checkConnections(stations, connections)
+	l.checkConnectivity(stations, connections)
+
+	sort.SliceStable(l.diagnostics, func(i, j int) bool {
+		if l.diagnostics[i].Line != l.diagnostics[j].Line {
+			return l.diagnostics[i].Line < l.diagnostics[j].Line
+		}
+		return l.diagnostics[i].Col < l.diagnostics[j].Col
+	})
+	return l.diagnostics, nil
+}
+
+// lintImported checks a map imported from JSON, YAML, CSV or a GTFS feed. The importer stops at the first problem,
+// which is reported as a single diagnostic; an imported network is checked for the same warnings
+// as a text map, with the position of every station and connection in its list as the line.
+// The returned error is only set when the map cannot be read.
+func lintImported(name string, network *Network, err error) ([]Diagnostic, error) {
+	l := &linter{file: name}
+	var lineErr *ErrInvalidLine
+	switch {
+	case errors.As(err, &lineErr):
+		l.report(lineErr.Line, lineErr.Col, SeverityError, "import", "invalid %s: %s", lineErr.Reason, lineErr.Text)
+		return l.diagnostics, nil
+	case errors.Is(err, ErrMissingSection):
+		l.report(1, 1, SeverityError, "missing-section", "%s", err)
+		return l.diagnostics, nil
+	case errors.As(err, new(*fs.PathError)):
+		return nil, err
+	case err != nil:
+		l.report(1, 1, SeverityError, "import", "%s", err)
+		return l.diagnostics, nil
+	}
+
+	stations := make([]lintStation, len(network.Stations))
+	for i, station := range network.Stations {
+		stations[i] = lintStation{Station: station, line: i + 1, col: 1}
+	}
+	connections := make([]lintConnection, len(network.Connections))
+	for i, conn := range network.Connections {
+		connections[i] = lintConnection{Connection: conn, line: i + 1, fromCol: 1, toCol: 1}
+	}
+	if limit := DefaultLimits.MaxStations; len(stations) > limit {
+		l.report(stations[limit].line, 1, SeverityError, "too-many-stations", "map contains more than %d stations", limit)
+	}
+	l.checkConnections(stations, connections)
+	l.checkConnectivity(stations, connections)
+	return l.diagnostics, nil
+}
+
+// checkStations reports duplicate station names and coordinates.
+func (l *linter) checkStations(stations []lintStation) {
+	names := make(map[string]lintStation)
+	coords := make(map[[2]int]lintStation)
+	for _, station := range stations {
+		if first, seen := names[station.Name]; seen {
+			l.report(station.line, station.col, SeverityError, "duplicate-station", "duplicate station name %s, first defined on line %d", station.Name, first.line)
+		} else {
+			names[station.Name] = station
+		}
+
+		key := [2]int{station.X, station.Y}
+		if first, seen := coords[key]; seen {
+			l.report(station.line, station.col, SeverityError, "duplicate-coordinates", "station %s is at %d, %d like %s on line %d", station.Name, station.X, station.Y, first.Name, first.line)
+		} else {
+			coords[key] = station
+		}
+	}
+}
+
+// checkConnections reports unknown stations, self-loops and duplicate connections.
+func (l *linter) checkConnections(stations []lintStation, connections []lintConnection) {
+	known := make(map[string]bool, len(stations))
+	for _, station := range stations {
+		known[station.Name] = true
+	}
+
+	seen := make(map[[2]string]lintConnection)
+	for _, conn := range connections {
+		if !known[conn.From] {
+			l.report(conn.line, conn.fromCol, SeverityError, "unknown-station", "connection from unknown station: %s", conn.From)
+		}
+		if !known[conn.To] {
+			l.report(conn.line, conn.toCol, SeverityError, "unknown-station", "connection to unknown station: %s", conn.To)
+		}
+		if conn.From == conn.To {
+			l.report(conn.line, conn.fromCol, SeverityWarning, "self-loop", "station %s is connected to itself", conn.From)
+			continue
+		}
+
+		duplicate := false
+		for _, direction := range conn.Directions() {
+			if first, dup := seen[direction]; dup {
+				l.report(conn.line, conn.fromCol, SeverityError, "duplicate-connection", "duplicate connection between %s and %s, first defined on line %d", conn.From, conn.To, first.line)
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			for _, direction := range conn.Directions() {
+				seen[direction] = conn
+			}
+		}
+	}
+}
+
+// checkConnectivity reports stations without connections and parts of the network
+// that cannot be reached from its largest connected component.
+func (l *linter) checkConnectivity(stations []lintStation, connections []lintConnection) {
+	adjacency := make(map[string][]string)
+	for _, conn := range connections {
+		adjacency[conn.From] = append(adjacency[conn.From], conn.To)
+		adjacency[conn.To] = append(adjacency[conn.To], conn.From)
+	}
+
+	component := make(map[string]int)
+	var components [][]lintStation
+	byName := make(map[string]lintStation, len(stations))
+	for _, station := range stations {
+		if _, dup := byName[station.Name]; !dup {
+			byName[station.Name] = station
+		}
+	}
+
+	for _, station := range stations {
+		if len(adjacency[station.Name]) == 0 {
+			l.report(station.line, station.col, SeverityWarning, "isolated-station", "station %s has no connections", station.Name)
+			continue
+		}
+		if _, done := component[station.Name]; done {
+			continue
+		}
+
+		id := len(components)
+		var members []lintStation
+		queue := []string{station.Name}
+		component[station.Name] = id
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+			if member, ok := byName[current]; ok {
+				members = append(members, member)
+			}
+			for _, next := range adjacency[current] {
+				if _, done := component[next]; !done {
+					component[next] = id
+					queue = append(queue, next)
+				}
+			}
+		}
+		components = append(components, members)
+	}
+
+	largest := 0
+	for i, members := range components {
+		if len(members) > len(components[largest]) {
+			largest = i
+		}
+	}
+	for i, members := range components {
+		if i == largest || len(members) == 0 {
+			continue
+		}
+		names := make([]string, 0, 3)
+		for _, member := range members[:min(3, len(members))] {
+			names = append(names, member.Name)
+		}
+		if len(members) > 3 {
+			names = append(names, "...")
+		}
+		l.report(members[0].line, members[0].col, SeverityWarning, "unreachable-component",
+			"%d station(s) not connected to the rest of the network: %s", len(members), strings.Join(names, ", "))
+	}
+}
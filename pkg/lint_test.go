@@ -0,0 +1,129 @@
+package train
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLintNetwork(t *testing.T) {
+	cases := []struct {
+		name    string
+		text    string
+		options ParseOptions
+		want    []string // line:col severity [code] of every diagnostic, in order
+	}{
+		{
+			name: "valid",
+			text: "stations:\na,0,0\nb,1,0\n\nconnections:\na-b\n",
+		},
+		{
+			name: "station values",
+			text: "stations:\n  a,0,0\nb, 1,x\n  c,2,0,cap=0\nd,3,0,cap=2,x\n\nconnections:\na-a2\n",
+			want: []string{
+				"3:6 error [bad-coordinates]",
+				"4:9 error [bad-capacity]",
+				"5:1 error [syntax]",
+				"8:3 error [unknown-station]",
+			},
+		},
+		{
+			name: "duplicates",
+			text: "stations:\na,0,0\n  a,1,1\nb,0,0\n\nconnections:\na-b\n  b - a\n",
+			want: []string{
+				"3:3 error [duplicate-station]",
+				"4:1 error [duplicate-coordinates]",
+				"8:3 error [duplicate-connection]",
+			},
+		},
+		{
+			name: "connection values",
+			text: "stations:\na,0,0\nb,1,0\n\nconnections:\na-b,0\n a-b,cap=x\na-b-c\n  a - zz\nyy->b\nb-b,2\na->b,2,cap=2\n",
+			want: []string{
+				"6:5 error [bad-weight]",
+				"7:6 error [bad-capacity]",
+				"8:1 error [syntax]",
+				"9:7 error [unknown-station]",
+				"10:1 error [unknown-station]",
+				"11:1 warning [self-loop]",
+			},
+		},
+		{
+			name: "unconnected stations",
+			text: "stations:\na,0,0\nb,1,0\nc,2,0\n  d,3,0\ne,4,0\n\nconnections:\na-b\nd-e\nstations:\n",
+			want: []string{
+				"4:1 warning [isolated-station]",
+				"5:3 warning [unreachable-component]",
+				"11:1 warning [duplicate-section]",
+			},
+		},
+		{
+			name: "missing sections",
+			text: "# stations:\n  a,0,0\n",
+			want: []string{
+				"1:1 error [missing-section]",
+				"1:1 error [missing-section]",
+				"2:3 error [syntax]",
+			},
+		},
+		{
+			name:    "too many lines",
+			text:    "stations:\na,0,0\nb,1,0\nconnections:\na-b\n",
+			options: ParseOptions{MaxLines: 3},
+			want: []string{
+				"1:1 error [missing-section]",
+				"2:1 warning [isolated-station]",
+				"3:1 warning [isolated-station]",
+				"4:1 error [too-many-lines]",
+			},
+		},
+		{
+			name:    "line too long",
+			text:    "stations:\na,0,0\nb,1,0 # " + strings.Repeat("-", 20) + "\nconnections:\na-b\n",
+			options: ParseOptions{MaxLineLength: 20},
+			want: []string{
+				"1:1 error [missing-section]",
+				"2:1 warning [isolated-station]",
+				"3:1 error [line-too-long]",
+			},
+		},
+		{
+			name: "json entry",
+			text: `{"stations": [{"name": "a", "x": 0, "y": 0}, {"name": "b", "x": -1, "y": 0}], "connections": []}`,
+			want: []string{"2:1 error [import]"},
+		},
+		{
+			name: "json warnings",
+			text: `{"stations": [{"name": "a", "x": 0, "y": 0}, {"name": "b", "x": 1, "y": 0}], "connections": [{"from": "a", "to": "a"}]}`,
+			want: []string{
+				"1:1 warning [self-loop]",
+				"2:1 warning [isolated-station]",
+			},
+		},
+		{
+			name: "yaml entry",
+			text: "stations:\n  - name: a\n    x: 0\n    y: zero\nconnections: []\n",
+			want: []string{"4:8 error [import]"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			diagnostics, err := LintNetwork(strings.NewReader(tc.text), "test.map", tc.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, d := range diagnostics {
+				if d.File != "test.map" {
+					t.Errorf("diagnostic of %s, want test.map: %s", d.File, d)
+				}
+				got = append(got, fmt.Sprintf("%d:%d %s [%s]", d.Line, d.Col, d.Severity, d.Code))
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("got diagnostics\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tc.want, "\n"))
+			}
+		})
+	}
+}
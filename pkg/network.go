@@ -0,0 +1,240 @@
+package train
+
+// Station is a named point of the network with map coordinates.
+type Station struct {
+	Name     string
+	X        int
+	Y        int
+	Capacity int // Trains the station can hold at once, 0 meaning 1
+}
+
+// Platforms returns how many trains the station can hold at once.
+func (s Station) Platforms() int {
+	return max(1, s.Capacity)
+}
+
+// Connection is a track between two stations.
+type Connection struct {
+	From     string
+	To       string
+	Weight   int  // Turns a train needs to travel the connection, 0 meaning 1
+	Capacity int  // Trains the connection carries at once, 0 meaning 1 (single track)
+	OneWay   bool // Trains may only travel from From to To
+}
+
+// Turns returns how many turns a train needs to travel the connection.
+func (c Connection) Turns() int {
+	return max(1, c.Weight)
+}
+
+// Directions returns the ordered station pairs trains may travel along the connection.
+func (c Connection) Directions() [][2]string {
+	if c.OneWay {
+		return [][2]string{{c.From, c.To}}
+	}
+	return [][2]string{{c.From, c.To}, {c.To, c.From}}
+}
+
+// track identifies the connection when checking its capacity. A one-way connection is
+// a track of its own, so a->b and b->a never block each other.
+func (c Connection) track() [2]string {
+	if c.OneWay {
+		return [2]string{c.From, c.To}
+	}
+	return trackKey(c.From, c.To)
+}
+
+// Tracks returns how many trains the connection carries at once, in either direction.
+// A single track carries one train, so trains can never meet head-on on it.
+func (c Connection) Tracks() int {
+	return max(1, c.Capacity)
+}
+
+// Network holds a parsed station map: its stations and the connections between them.
+type Network struct {
+	Stations    []Station
+	Connections []Connection
+}
+
+// Graph is the adjacency view of a network used by the path searches.
+type Graph struct {
+	AdjList  map[string][]string
+	Stations map[string]Station
+	weights  map[[2]string]int
+	tracks   map[[2]string]int
+	oneWay   map[[2]string]bool
+	order    map[string]int // Position of every station in the map
+}
+
+// NewGraph builds the adjacency list and station index of the network.
+func NewGraph(network *Network) *Graph {
+	adjList := make(map[string][]string)
+	stationMap := make(map[string]Station)
+	weights := make(map[[2]string]int)
+	tracks := make(map[[2]string]int)
+	oneWay := make(map[[2]string]bool)
+
+	for _, conn := range network.Connections {
+		if _, ok := adjList[conn.From]; !ok {
+			adjList[conn.From] = []string{}
+		}
+		if _, ok := adjList[conn.To]; !ok {
+			adjList[conn.To] = []string{}
+		}
+		for _, direction := range conn.Directions() {
+			adjList[direction[0]] = append(adjList[direction[0]], direction[1])
+			if conn.Turns() > 1 {
+				weights[direction] = conn.Turns()
+			}
+		}
+		if conn.OneWay {
+			oneWay[[2]string{conn.From, conn.To}] = true
+		}
+		if conn.Tracks() > 1 {
+			tracks[conn.track()] = conn.Tracks()
+		}
+	}
+
+	order := make(map[string]int, len(network.Stations))
+	for i, station := range network.Stations {
+		stationMap[station.Name] = station
+		order[station.Name] = i
+	}
+
+	return &Graph{AdjList: adjList, Stations: stationMap, weights: weights, tracks: tracks, oneWay: oneWay, order: order}
+}
+
+// Weight returns how many turns a train needs to travel from one station to a neighbour.
+func (g *Graph) Weight(from, to string) int {
+	if weight, ok := g.weights[[2]string{from, to}]; ok {
+		return weight
+	}
+	return 1
+}
+
+// Capacity returns how many trains a station can hold at once.
+// A nil graph or an unknown station gives a capacity of 1.
+func (g *Graph) Capacity(name string) int {
+	if g == nil {
+		return 1
+	}
+	return g.Stations[name].Platforms()
+}
+
+// Tracks returns how many trains the connection between two stations carries at once.
+// A nil graph gives every connection a single track.
+func (g *Graph) Tracks(a, b string) int {
+	if g == nil {
+		return 1
+	}
+	if tracks, ok := g.tracks[g.track(a, b)]; ok {
+		return tracks
+	}
+	return 1
+}
+
+// track identifies the connection a train uses from one station to the next when checking
+// its capacity. A nil graph treats every connection as usable in both directions.
+func (g *Graph) track(from, to string) [2]string {
+	if g != nil && g.oneWay[[2]string{from, to}] {
+		return [2]string{from, to}
+	}
+	return trackKey(from, to)
+}
+
+// trackKey identifies the connection between two stations regardless of direction.
+func trackKey(a, b string) [2]string {
+	if a > b {
+		a, b = b, a
+	}
+	return [2]string{a, b}
+}
+
+// position returns where the map lists the station. A nil graph lists every station first.
+func (g *Graph) position(name string) int {
+	if g == nil {
+		return 0
+	}
+	return g.order[name]
+}
+
+// Weighted reports whether any connection takes more than one turn.
+func (g *Graph) Weighted() bool {
+	return len(g.weights) > 0
+}
+
+// RouteTimer measures how long trains need on routes that leave from Start.
+// A zero RouteTimer treats every connection as taking one turn.
+type RouteTimer struct {
+	Graph *Graph
+	Start string
+}
+
+// weight returns the turns needed from one station to the next.
+func (t RouteTimer) weight(from, to string) int {
+	if t.Graph == nil {
+		return 1
+	}
+	return t.Graph.Weight(from, to)
+}
+
+// Duration returns the turns a single train needs to travel the route, which excludes the start station.
+func (t RouteTimer) Duration(route []string) int {
+	duration := 0
+	previous := t.Start
+	for _, station := range route {
+		duration += t.weight(previous, station)
+		previous = station
+	}
+	return duration
+}
+
+// Headway returns the turns between two trains following each other on the route.
+// A connection carries as many trains at a time as it has tracks, and a station holds a
+// train from the turn it leaves for the station until it leaves it again, so the connection
+// or station with the most turns per track or platform sets the pace.
+func (t RouteTimer) Headway(route []string) int {
+	return t.sharedHeadway(route, t.loads([][]string{route}))
+}
+
+// routeLoads are the turns the routes of a combination hold every connection and station
+// for, one train per route.
+type routeLoads struct {
+	tracks   map[[2]string]int
+	stations map[string]int
+}
+
+// loads sums the turns the routes hold every connection and every station but the end one,
+// which takes any number of trains.
+func (t RouteTimer) loads(routes [][]string) routeLoads {
+	loads := routeLoads{tracks: make(map[[2]string]int), stations: make(map[string]int)}
+	for _, route := range routes {
+		previous := t.Start
+		for i, station := range route {
+			weight := t.weight(previous, station)
+			loads.tracks[t.Graph.track(previous, station)] += weight
+			if i < len(route)-1 {
+				loads.stations[station] += weight
+			}
+			previous = station
+		}
+	}
+	return loads
+}
+
+// sharedHeadway returns the headway of the route when its trains take turns with those of
+// the other routes of a combination on the connections and stations they share.
+func (t RouteTimer) sharedHeadway(route []string, loads routeLoads) int {
+	headway := 1
+	previous := t.Start
+	for i, station := range route {
+		tracks := t.Graph.Tracks(previous, station)
+		headway = max(headway, (loads.tracks[t.Graph.track(previous, station)]+tracks-1)/tracks)
+		if i < len(route)-1 {
+			platforms := t.Graph.Capacity(station)
+			headway = max(headway, (loads.stations[station]+platforms-1)/platforms)
+		}
+		previous = station
+	}
+	return headway
+}
@@ -2,79 +2,140 @@ package train
 
 import (
 	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"strings"
 )
 
-type Station struct {
-	Name string
-	X    int
-	Y    int
+// DefaultMaxLineLength is the longest map line, in bytes, accepted when ParseOptions sets no limit.
+const DefaultMaxLineLength = 1 << 20
+
+// ParseOptions limits what a map may contain.
+type ParseOptions struct {
+	MaxLineLength int // Longest line in bytes, 0 meaning DefaultMaxLineLength
+	MaxLines      int // Most lines in the map, 0 for no limit
 }
 
-// ParseNetworkMap reads the network map file and returns a list of stations and a 2D slice of connections.
-func ParseNetworkMap(filePath string) ([]Station, [][]string, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, nil, err
+// lineScanner returns a scanner over the lines of r that accepts lines up to the configured length,
+// not counting the line break.
+func (o ParseOptions) lineScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	maxLength := o.maxLineLength()
+	scanner.Buffer(make([]byte, 0, min(64*1024, maxLength+2)), maxLength+2) // Room for "\r\n"
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := bufio.ScanLines(data, atEOF)
+		if len(token) > maxLength {
+			return 0, nil, bufio.ErrTooLong
+		}
+		return advance, token, err
+	})
+	return scanner
+}
+
+func (o ParseOptions) maxLineLength() int {
+	if o.MaxLineLength > 0 {
+		return o.MaxLineLength
 	}
-	defer file.Close()
+	return DefaultMaxLineLength
+}
 
-	var stations []Station
-	var connections [][]string
-	scanner := bufio.NewScanner(file)
-	mode := ""
-	stationsSectionFound := false
-	connectionsSectionFound := false
+// scanError converts the error that stopped a line scanner after lineNumber lines.
+func (o ParseOptions) scanError(err error, lineNumber int) error {
+	if errors.Is(err, bufio.ErrTooLong) {
+		return &ErrInvalidLine{Reason: "line length", Text: fmt.Sprintf("longer than %d bytes", o.maxLineLength()), Line: lineNumber + 1, Col: 1}
+	}
+	return err
+}
 
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
+// Magic numbers at the start of compressed maps.
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
 
-		if hashIndex := strings.Index(line, "#"); hashIndex != -1 {
-			line = strings.TrimSpace(line[:hashIndex])
-		}
+// decompress returns a reader of the map in r, which may be gzip compressed.
+// Compression is recognised by the content rather than a file extension, so that
+// compressed maps can also be piped in.
+func decompress(r io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(r)
+	magic, _ := buffered.Peek(len(zstdMagic))
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		return gzip.NewReader(buffered)
+	case bytes.HasPrefix(magic, zstdMagic):
+		return nil, fmt.Errorf("zstd compressed maps are not supported, decompress the map with 'zstd -d' or compress it with gzip")
+	}
+	return buffered, nil
+}
 
-		if len(line) == 0 {
-			continue
-		}
+// ParseNetworkMap reads the network map file, which may be gzip compressed, and returns the validated
+// network. The file may also be a JSON or YAML map or a GTFS feed, and a directory is read as a CSV map with
+// ParseNetworkDir.
+func ParseNetworkMap(filePath string) (*Network, error) {
+	return ParseNetworkMapWithOptions(filePath, ParseOptions{})
+}
 
-		if line == "stations:" {
-			stationsSectionFound = true
-		}
-		if line == "connections:" {
-			connectionsSectionFound = true
-		}
+// ParseNetworkMapWithOptions reads the network map file like ParseNetworkMap, within the limits
+// of options on its lines.
+func ParseNetworkMapWithOptions(filePath string, options ParseOptions) (*Network, error) {
+	if info, err := os.Stat(filePath); err == nil && info.IsDir() {
+		return ParseNetworkDir(filePath)
 	}
 
-	err = CheckSections(stationsSectionFound, connectionsSectionFound)
+	file, err := os.Open(filePath)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
+	defer file.Close()
 
-	file.Seek(0, 0) // Reset file pointer to the beginning
-	scanner = bufio.NewScanner(file)
+	return ParseNetwork(file, options)
+}
 
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
+// ParseNetwork reads a network map in a single pass over r and returns the validated network.
+// The map may be gzip compressed, a map starting with "{" is read as JSON with ParseNetworkJSON,
+// a YAML map as told by isYAML with ParseNetworkYAML and a zip archive as a GTFS feed with ReadGTFS.
+// A missing section is reported before any invalid line, as the whole map has to be read to tell.
+func ParseNetwork(r io.Reader, options ParseOptions) (*Network, error) {
+	r, err := decompress(r)
+	if err != nil {
+		return nil, err
+	}
+	buffered := bufio.NewReader(r)
+	switch {
+	case isJSON(buffered):
+		return ParseNetworkJSON(buffered)
+	case isYAML(buffered):
+		return ParseNetworkYAML(buffered)
+	case isGTFS(buffered):
+		return readGTFSStream(buffered)
+	}
+	r = buffered
+
+	var stations []Station
+	var connections []Connection
+	mode := ""
+	stationsSectionFound := false
+	connectionsSectionFound := false
+	var invalidLine *ErrInvalidLine // First invalid line; only section headers are looked for after it
 
-		// Ignore comments
-		if hashIndex := strings.Index(line, "#"); hashIndex != -1 {
-			line = strings.TrimSpace(line[:hashIndex])
+	scanner := options.lineScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		if options.MaxLines > 0 && lineNumber > options.MaxLines {
+			return nil, &detailedError{ErrMapTooLarge, fmt.Sprintf("Map has more than %d lines", options.MaxLines)}
 		}
+		line, col := cleanLine(scanner.Text())
 
 		if len(line) == 0 {
 			continue
 		}
 
-		if len(stations) > 10000 {
-			return nil, nil,
-
-				fmt.Errorf("Error: Map contains more than 10000 stations")
-
-		}
-
 		if line == "stations:" {
 			mode = "stations"
 			stationsSectionFound = true
@@ -85,58 +146,185 @@ func ParseNetworkMap(filePath string) ([]Station, [][]string, error) {
 			connectionsSectionFound = true
 			continue
 		}
-
-		if !stationsSectionFound {
-			return nil, nil, fmt.Errorf("Error: Map does not contain 'stations:' section")
-		}
-		if !connectionsSectionFound {
-			return nil, nil, fmt.Errorf("Error: Map does not contain 'connections:' section")
+		if invalidLine != nil {
+			continue
 		}
 
 		switch mode {
 		case "stations":
-			parts := strings.Split(line, ",")
-			if len(parts) != 3 {
-				return nil, nil, fmt.Errorf("error: invalid station format on line: %s", line)
+			station, _, lineErr := parseStationLine(line, col)
+			if lineErr != nil {
+				lineErr.Line = lineNumber
+				invalidLine = lineErr
+				continue
 			}
-			name := strings.TrimSpace(parts[0])
-			x, err1 := strconv.Atoi(strings.TrimSpace(parts[1]))
-			y, err2 := strconv.Atoi(strings.TrimSpace(parts[2]))
-			if err1 != nil || err2 != nil || x < 0 || y < 0 {
-				return nil, nil, fmt.Errorf("error: invalid station coordinates on line: %s", line)
-			}
-			stations = append(stations, Station{Name: name, X: x, Y: y})
+			stations = append(stations, station)
 		case "connections":
-			parts := strings.Split(line, "-")
-			if len(parts) != 2 {
-				return nil, nil, fmt.Errorf("error: invalid connection format on line: %s", line)
+			connection, _, lineErr := parseConnectionLine(line, col)
+			if lineErr != nil {
+				lineErr.Line = lineNumber
+				invalidLine = lineErr
+				continue
 			}
-			from := strings.TrimSpace(parts[0])
-			to := strings.TrimSpace(parts[1])
-			connections = append(connections, []string{from, to})
+			connections = append(connections, connection)
 		default:
-			return nil, nil, fmt.Errorf("error: invalid format on line: %s", line)
+			invalidLine = &ErrInvalidLine{Reason: "format", Text: line, Line: lineNumber, Col: col}
 		}
 	}
+	if err := scanner.Err(); err != nil {
+		return nil, options.scanError(err, lineNumber)
+	}
 
-	err = CheckDuplicateStationNames(stations)
-	if err != nil {
-		return nil, nil, err
+	if err := CheckSections(stationsSectionFound, connectionsSectionFound); err != nil {
+		return nil, err
+	}
+	if invalidLine != nil {
+		return nil, invalidLine
 	}
 
-	err = CheckDuplicateCoordinates(stations)
-	if err != nil {
-		return nil, nil, err
+	return newNetwork(stations, connections)
+}
+
+// newNetwork runs the checks shared by every map format and returns the network.
+func newNetwork(stations []Station, connections []Connection) (*Network, error) {
+	if err := CheckDuplicateStationNames(stations); err != nil {
+		return nil, err
 	}
 
-	err = CheckDuplicateRoutes(connections)
-	if err != nil {
-		return nil, nil, err
+	if err := CheckDuplicateCoordinates(stations); err != nil {
+		return nil, err
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, nil, err
+	if err := CheckDuplicateRoutes(connections); err != nil {
+		return nil, err
+	}
+
+	return &Network{Stations: stations, Connections: connections}, nil
+}
+
+type field struct {
+	text string
+	col  int
+}
+
+// cleanLine strips the comment and the surrounding spaces from a raw map line and
+// returns what is left together with the 1-based column where it starts.
+func cleanLine(raw string) (string, int) {
+	if hashIndex := strings.Index(raw, "#"); hashIndex != -1 {
+		raw = raw[:hashIndex]
+	}
+	trimmed := strings.TrimLeft(raw, " \t")
+	col := len(raw) - len(trimmed) + 1
+	return strings.TrimRight(trimmed, " \t\r"), col
+}
+
+// splitFields splits text on sep. col is the column of the first character of text.
+func splitFields(text string, col int, sep string) []field {
+	var fields []field
+	for _, part := range strings.Split(text, sep) {
+		trimmed := strings.TrimLeft(part, " \t")
+		fields = append(fields, field{
+			text: strings.TrimSpace(trimmed),
+			col:  col + len(part) - len(trimmed),
+		})
+		col += len(part) + len(sep)
+	}
+	return fields
+}
+
+// parseStationLine parses a "name,x,y" line, optionally followed by ",cap=n" giving the number
+// of trains the station can hold at once. The returned fields hold the name and the coordinates.
+func parseStationLine(line string, col int) (Station, []field, *ErrInvalidLine) {
+	fields := splitFields(line, col, ",")
+	if len(fields) != 3 && len(fields) != 4 {
+		return Station{}, fields, &ErrInvalidLine{Reason: "station format", Text: line, Col: col}
+	}
+	x, err := strconv.Atoi(fields[1].text)
+	if err != nil || x < 0 {
+		return Station{}, fields, &ErrInvalidLine{Reason: "station coordinates", Text: line, Col: fields[1].col}
+	}
+	y, err := strconv.Atoi(fields[2].text)
+	if err != nil || y < 0 {
+		return Station{}, fields, &ErrInvalidLine{Reason: "station coordinates", Text: line, Col: fields[2].col}
+	}
+
+	capacity := 1
+	if len(fields) == 4 {
+		value, ok := strings.CutPrefix(fields[3].text, "cap=")
+		capacity, err = strconv.Atoi(value)
+		if !ok || err != nil || capacity < 1 {
+			return Station{}, fields, &ErrInvalidLine{Reason: "station capacity", Text: line, Col: fields[3].col}
+		}
+	}
+	return Station{Name: fields[0].text, X: x, Y: y, Capacity: capacity}, fields[:3], nil
+}
+
+// parseConnectionLine parses an "a-b" line, or "a->b" for a one-way connection, optionally followed by ",n" giving the number of
+// turns a train needs to travel the connection and by ",cap=n" giving the number of trains it
+// carries at once. The returned fields hold the two station names.
+func parseConnectionLine(line string, col int) (Connection, []field, *ErrInvalidLine) {
+	parts := splitFields(line, col, ",")
+	if len(parts) > 3 {
+		return Connection{}, nil, &ErrInvalidLine{Reason: "connection format", Text: line, Col: col}
+	}
+
+	weight, capacity := 1, 1
+	for i, part := range parts[1:] {
+		if value, ok := strings.CutPrefix(part.text, "cap="); ok {
+			var err error
+			capacity, err = strconv.Atoi(value)
+			if err != nil || capacity < 1 || i != len(parts)-2 {
+				return Connection{}, nil, &ErrInvalidLine{Reason: "connection capacity", Text: line, Col: part.col}
+			}
+			continue
+		}
+		var err error
+		weight, err = strconv.Atoi(part.text)
+		if err != nil || weight < 1 || i != 0 {
+			return Connection{}, nil, &ErrInvalidLine{Reason: "connection weight", Text: line, Col: part.col}
+		}
+	}
+
+	separator, oneWay := "-", strings.Contains(parts[0].text, "->")
+	if oneWay {
+		separator = "->"
+	}
+	fields := splitFields(parts[0].text, parts[0].col, separator)
+	if len(fields) != 2 {
+		return Connection{}, fields, &ErrInvalidLine{Reason: "connection format", Text: line, Col: col}
+	}
+	return Connection{From: fields[0].text, To: fields[1].text, Weight: weight, Capacity: capacity, OneWay: oneWay}, fields, nil
+}
+
+// WriteNetwork writes the network in the text map format read by ParseNetwork, leaving out
+// capacities and weights of 1, so that imported maps can be saved and edited.
+func WriteNetwork(w io.Writer, network *Network) error {
+	var b strings.Builder
+	b.WriteString("stations:\n")
+	for _, station := range network.Stations {
+		fmt.Fprintf(&b, "%s,%d,%d", station.Name, station.X, station.Y)
+		if station.Platforms() > 1 {
+			fmt.Fprintf(&b, ",cap=%d", station.Platforms())
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\nconnections:\n")
+	for _, conn := range network.Connections {
+		separator := "-"
+		if conn.OneWay {
+			separator = "->"
+		}
+		fmt.Fprintf(&b, "%s%s%s", conn.From, separator, conn.To)
+		if conn.Turns() > 1 {
+			fmt.Fprintf(&b, ",%d", conn.Turns())
+		}
+		if conn.Tracks() > 1 {
+			fmt.Fprintf(&b, ",cap=%d", conn.Tracks())
+		}
+		b.WriteString("\n")
 	}
-	return stations, connections, nil
 
+	_, err := io.WriteString(w, b.String())
+	return err
 }
@@ -0,0 +1,75 @@
+package train
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// limitedMap has 6 lines, the longest being the 12 bytes of "connections:".
+const limitedMap = "stations:\na,0,0\nb,1,0\n\nconnections:\na-b\n"
+
+func TestParseOptions(t *testing.T) {
+	cases := []struct {
+		name    string
+		text    string
+		options ParseOptions
+		line    int  // Line reported as too long, 0 when no line is
+		tooBig  bool // Whether the map has more lines than allowed
+	}{
+		{name: "no limits", text: limitedMap},
+		{name: "longest line", text: limitedMap, options: ParseOptions{MaxLineLength: 12}},
+		{name: "longest line with carriage return", text: strings.ReplaceAll(limitedMap, "\n", "\r\n"), options: ParseOptions{MaxLineLength: 12}},
+		{name: "line too long", text: limitedMap, options: ParseOptions{MaxLineLength: 11}, line: 5},
+		{name: "line too long with carriage return", text: strings.ReplaceAll(limitedMap, "\n", "\r\n"), options: ParseOptions{MaxLineLength: 11}, line: 5},
+		{name: "last line too long", text: "stations:\na,0,0\nb,1,0\nconnections:\na-b # " + strings.Repeat("-", 20), options: ParseOptions{MaxLineLength: 20}, line: 5},
+		{name: "comment beyond the default buffer", text: "# " + strings.Repeat("-", 100_000) + "\n" + limitedMap},
+		{name: "comment beyond the default limit", text: "# " + strings.Repeat("-", DefaultMaxLineLength) + "\n" + limitedMap, line: 1},
+		{name: "most lines", text: limitedMap, options: ParseOptions{MaxLines: 6}},
+		{name: "too many lines", text: limitedMap, options: ParseOptions{MaxLines: 5}, tooBig: true},
+		{name: "blank lines count", text: limitedMap + "\n\n", options: ParseOptions{MaxLines: 7}, tooBig: true},
+		{name: "both limits", text: limitedMap, options: ParseOptions{MaxLineLength: 12, MaxLines: 6}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			network, err := ParseNetwork(strings.NewReader(tc.text), tc.options)
+			var lineErr *ErrInvalidLine
+			switch {
+			case tc.line > 0:
+				if !errors.As(err, &lineErr) || lineErr.Reason != "line length" || lineErr.Line != tc.line {
+					t.Errorf("got error %v, want line %d too long", err, tc.line)
+				}
+			case tc.tooBig:
+				if !errors.Is(err, ErrMapTooLarge) {
+					t.Errorf("got error %v, want %v", err, ErrMapTooLarge)
+				}
+			case err != nil:
+				t.Fatal(err)
+			case len(network.Stations) != 2 || len(network.Connections) != 1:
+				t.Errorf("read %d stations and %d connections, want 2 and 1", len(network.Stations), len(network.Connections))
+			}
+		})
+	}
+}
+
+// TestParseNetworkMapWithOptions checks that the options apply to map files.
+func TestParseNetworkMapWithOptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "network.map")
+	if err := os.WriteFile(path, []byte(limitedMap), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ParseNetworkMapWithOptions(path, ParseOptions{MaxLineLength: 12, MaxLines: 6}); err != nil {
+		t.Errorf("got error %v within the limits", err)
+	}
+	var lineErr *ErrInvalidLine
+	if _, err := ParseNetworkMapWithOptions(path, ParseOptions{MaxLineLength: 11}); !errors.As(err, &lineErr) || lineErr.Line != 5 {
+		t.Errorf("got error %v, want line 5 too long", err)
+	}
+	if _, err := ParseNetworkMapWithOptions(path, ParseOptions{MaxLines: 5}); !errors.Is(err, ErrMapTooLarge) {
+		t.Errorf("got error %v, want %v", err, ErrMapTooLarge)
+	}
+}
@@ -0,0 +1,122 @@
+package train
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"slices"
+)
+
+// shortestPathAvoiding finds the fastest path from start to end with Dijkstra's algorithm, skipping
+// the avoided stations and hops. The path includes both ends and is nil when end cannot be reached.
+func shortestPathAvoiding(graph *Graph, start, end string, avoidStations map[string]bool, avoidHops map[[2]string]bool) []string {
+	pq := &PriorityQueue{}
+	heap.Push(pq, &Node{station: start, priority: 0})
+	cameFrom := map[string]string{start: ""}
+	costSoFar := map[string]int{start: 0}
+
+	for pq.Len() > 0 {
+		node := heap.Pop(pq).(*Node)
+		current := node.station
+		if node.priority > costSoFar[current] {
+			continue // Superseded by a cheaper entry
+		}
+
+		if current == end {
+			path := []string{}
+			for current != "" {
+				path = append(path, current)
+				current = cameFrom[current]
+			}
+			slices.Reverse(path)
+			return path
+		}
+
+		for _, next := range graph.AdjList[current] {
+			if avoidStations[next] || avoidHops[[2]string{current, next}] {
+				continue
+			}
+			newCost := costSoFar[current] + graph.Weight(current, next)
+			if cost, ok := costSoFar[next]; !ok || newCost < cost {
+				costSoFar[next] = newCost
+				cameFrom[next] = current
+				heap.Push(pq, &Node{station: next, priority: newCost})
+			}
+		}
+	}
+	return nil
+}
+
+// pathTurns returns the turns a train needs along a path that includes its start station.
+func pathTurns(graph *Graph, path []string) int {
+	turns := 0
+	for i := 1; i < len(path); i++ {
+		turns += graph.Weight(path[i-1], path[i])
+	}
+	return turns
+}
+
+// KShortestRoutes returns up to k of the fastest routes from start to end that visit no station
+// twice, fastest first, with Yen's algorithm. Routes exclude the start station.
+// The search stops with the error of ctx once ctx is done.
+func KShortestRoutes(ctx context.Context, graph *Graph, start, end string, k int) ([][]string, error) {
+	if k <= 0 {
+		return nil, nil
+	}
+	first := shortestPathAvoiding(graph, start, end, nil, nil)
+	if first == nil {
+		return nil, ValidatePathExistence(nil, start, end)
+	}
+
+	paths := [][]string{first}
+	var candidates [][]string
+	seen := map[string]bool{fmt.Sprint(first): true}
+	for len(paths) < k {
+		// Every path found so far branches off the previous one at one of its stations
+		previous := paths[len(paths)-1]
+		for i := 0; i < len(previous)-1; i++ {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			root := previous[:i+1]
+			avoidStations := make(map[string]bool, i)
+			for _, station := range root[:i] {
+				avoidStations[station] = true
+			}
+			avoidHops := make(map[[2]string]bool)
+			for _, path := range paths {
+				if len(path) > i+1 && slices.Equal(path[:i+1], root) {
+					avoidHops[[2]string{path[i], path[i+1]}] = true
+				}
+			}
+
+			spur := shortestPathAvoiding(graph, root[i], end, avoidStations, avoidHops)
+			if spur == nil {
+				continue
+			}
+			path := append(slices.Clone(root[:i]), spur...)
+			if key := fmt.Sprint(path); !seen[key] {
+				seen[key] = true
+				candidates = append(candidates, path)
+			}
+		}
+		if len(candidates) == 0 {
+			break
+		}
+
+		fastest := 0
+		for i, candidate := range candidates {
+			if pathTurns(graph, candidate) < pathTurns(graph, candidates[fastest]) {
+				fastest = i
+			}
+		}
+		paths = append(paths, candidates[fastest])
+		candidates = slices.Delete(candidates, fastest, fastest+1)
+	}
+
+	routes := make([][]string, len(paths))
+	for i, path := range paths {
+		routes[i] = path[1:] // Exclude the start station
+	}
+	return routes, nil
+}
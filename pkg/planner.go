@@ -0,0 +1,152 @@
+package train
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// Planner computes train schedules for a parsed network without touching
+// process arguments, standard output or the exit status.
+type Planner struct {
+	// Scheduler computes the schedule. When nil, AutoScheduler picks one by network size.
+	Scheduler Scheduler
+	// Limits rejects networks with too many stations, and is passed on to the AutoScheduler used
+	// when Scheduler is nil. The zero value means DefaultLimits.
+	Limits Limits
+}
+
+// Demand asks for a number of trains to travel from one station to another.
+type Demand struct {
+	From   string
+	To     string
+	Trains int
+}
+
+// Plan validates the request and schedules the given number of trains from start to end over the network.
+func (p *Planner) Plan(ctx context.Context, network *Network, start, end string, trains int) (*Schedule, error) {
+	if err := p.validateRequest(ctx, network, start, end, trains); err != nil {
+		return nil, err
+	}
+	return p.scheduler().Schedule(ctx, network, start, end, trains)
+}
+
+// validateRequest checks a request for trains from start to end before it is scheduled.
+func (p *Planner) validateRequest(ctx context.Context, network *Network, start, end string, trains int) error {
+	if err := p.Limits.Check(MeasureNetwork(network)); err != nil {
+		return err
+	}
+	if err := ValidateTrainCount(trains, nil); err != nil {
+		return err
+	}
+	if err := ValidateDifferentStations(start, end); err != nil {
+		return err
+	}
+	if err := ValidateStationExistence(network.Stations, start, end); err != nil {
+		return err
+	}
+	if err := CheckConnectionsExist(network.Stations, network.Connections); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+func (p *Planner) scheduler() Scheduler {
+	if p.Scheduler == nil {
+		return AutoScheduler{Limits: p.Limits}
+	}
+	return p.Scheduler
+}
+
+// PlanDemands schedules every demand on its own and then simulates all trains together, so that
+// trains of different demands never share a station or a connection beyond its capacity.
+// Trains are numbered across the demands in order and earlier demands move first when trains compete.
+func (p *Planner) PlanDemands(ctx context.Context, network *Network, demands []Demand) (*Schedule, error) {
+	if len(demands) == 0 {
+		return nil, &detailedError{ErrInvalidTrainCount, "No demands given"}
+	}
+
+	schedules := make([]*Schedule, 0, len(demands))
+	for _, demand := range demands {
+		schedule, err := p.Plan(ctx, network, demand.From, demand.To, demand.Trains)
+		if err != nil {
+			return nil, fmt.Errorf("%s to %s: %w", demand.From, demand.To, err)
+		}
+		schedules = append(schedules, schedule)
+	}
+	return PlanDemandMovements(demands, schedules, NewGraph(network))
+}
+
+// PlanTimetable schedules the trains of a timetable. Trains running between the same stations form
+// a demand. The routes of a demand are chosen for the paces of its trains when the scheduler is a
+// FleetRouter, and every train is then assigned to the route where it arrives first, the earliest
+// departures choosing first. All trains are simulated together, keeping their departure turns and
+// paces and moving trains with a higher priority or an earlier deadline first. When trains still
+// arrive too late, PlanTimetable fails with ErrMissedDeadlines listing them.
+func (p *Planner) PlanTimetable(ctx context.Context, network *Network, specs []TrainSpec) (*Schedule, error) {
+	var demands []Demand
+	var members [][]int // Spec indices of every demand
+	demandIndex := make(map[[2]string]int)
+	for i, spec := range specs {
+		key := [2]string{spec.From, spec.To}
+		idx, ok := demandIndex[key]
+		if !ok {
+			idx = len(demands)
+			demandIndex[key] = idx
+			demands = append(demands, Demand{From: spec.From, To: spec.To})
+			members = append(members, nil)
+		}
+		demands[idx].Trains++
+		members[idx] = append(members[idx], i)
+	}
+	if len(demands) == 0 {
+		return nil, &detailedError{ErrInvalidTrainCount, "No trains given"}
+	}
+
+	graph := NewGraph(network)
+	trains := make([]combinedTrain, len(specs))
+	demandRoutes := make([][][]string, len(demands))
+	for demandIdx, demand := range demands {
+		indices := members[demandIdx]
+		sort.SliceStable(indices, func(i, j int) bool {
+			a, b := specs[indices[i]], specs[indices[j]]
+			if a.Departure != b.Departure {
+				return a.Departure < b.Departure
+			}
+			return a.Priority > b.Priority
+		})
+		paces := make([]int, len(indices))
+		departures := make([]int, len(indices))
+		for k, specIdx := range indices {
+			paces[k] = specs[specIdx].Paces()
+			departures[k] = specs[specIdx].Departure
+		}
+
+		routes, err := p.fleetRoutes(ctx, network, demand, paces)
+		if err != nil {
+			return nil, fmt.Errorf("%s to %s: %w", demand.From, demand.To, err)
+		}
+		durations, headways := calculateRouteTimes(routes, RouteTimer{Graph: graph, Start: demand.From})
+		assignment, _ := allocateFleet(durations, headways, paces, departures)
+		for k, specIdx := range indices {
+			trains[specIdx] = combinedTrain{demand: demandIdx, route: assignment[k], spec: specs[specIdx]}
+		}
+		demandRoutes[demandIdx] = routes
+	}
+	return planCombinedMovements(demands, demandRoutes, trains, graph)
+}
+
+// fleetRoutes returns the routes of a demand for trains with the given paces.
+func (p *Planner) fleetRoutes(ctx context.Context, network *Network, demand Demand, paces []int) ([][]string, error) {
+	if err := p.validateRequest(ctx, network, demand.From, demand.To, len(paces)); err != nil {
+		return nil, err
+	}
+	if router, ok := p.scheduler().(FleetRouter); ok {
+		return router.FleetRoutes(ctx, network, demand.From, demand.To, paces)
+	}
+	schedule, err := p.scheduler().Schedule(ctx, network, demand.From, demand.To, len(paces))
+	if err != nil {
+		return nil, err
+	}
+	return schedule.Routes, nil
+}
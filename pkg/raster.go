@@ -0,0 +1,218 @@
+package train
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"strconv"
+	"unicode"
+)
+
+// Colours of a PNG drawing.
+var (
+	pngBackground = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	pngTrack      = color.RGBA{0x99, 0x99, 0x99, 0xff}
+	pngInk        = color.RGBA{0x00, 0x00, 0x00, 0xff}
+)
+
+// glyphScale is the size in pixels of a dot of the label font.
+const glyphScale = 2
+
+// glyphs is a 3×5 dot font for the labels of a PNG drawing. Each row holds three dots, the
+// leftmost in the highest bit. Letters are drawn in capitals and unknown characters as '?'.
+var glyphs = map[rune][5]uint8{
+	'A': {0b010, 0b101, 0b111, 0b101, 0b101},
+	'B': {0b110, 0b101, 0b110, 0b101, 0b110},
+	'C': {0b011, 0b100, 0b100, 0b100, 0b011},
+	'D': {0b110, 0b101, 0b101, 0b101, 0b110},
+	'E': {0b111, 0b100, 0b110, 0b100, 0b111},
+	'F': {0b111, 0b100, 0b110, 0b100, 0b100},
+	'G': {0b011, 0b100, 0b101, 0b101, 0b011},
+	'H': {0b101, 0b101, 0b111, 0b101, 0b101},
+	'I': {0b111, 0b010, 0b010, 0b010, 0b111},
+	'J': {0b001, 0b001, 0b001, 0b101, 0b010},
+	'K': {0b101, 0b101, 0b110, 0b101, 0b101},
+	'L': {0b100, 0b100, 0b100, 0b100, 0b111},
+	'M': {0b101, 0b111, 0b111, 0b101, 0b101},
+	'N': {0b110, 0b101, 0b101, 0b101, 0b101},
+	'O': {0b010, 0b101, 0b101, 0b101, 0b010},
+	'P': {0b110, 0b101, 0b110, 0b100, 0b100},
+	'Q': {0b010, 0b101, 0b101, 0b110, 0b011},
+	'R': {0b110, 0b101, 0b110, 0b101, 0b101},
+	'S': {0b011, 0b100, 0b010, 0b001, 0b110},
+	'T': {0b111, 0b010, 0b010, 0b010, 0b010},
+	'U': {0b101, 0b101, 0b101, 0b101, 0b111},
+	'V': {0b101, 0b101, 0b101, 0b101, 0b010},
+	'W': {0b101, 0b101, 0b111, 0b111, 0b101},
+	'X': {0b101, 0b101, 0b010, 0b101, 0b101},
+	'Y': {0b101, 0b101, 0b010, 0b010, 0b010},
+	'Z': {0b111, 0b001, 0b010, 0b100, 0b111},
+	'0': {0b111, 0b101, 0b101, 0b101, 0b111},
+	'1': {0b010, 0b110, 0b010, 0b010, 0b111},
+	'2': {0b110, 0b001, 0b010, 0b100, 0b111},
+	'3': {0b110, 0b001, 0b010, 0b001, 0b110},
+	'4': {0b101, 0b101, 0b111, 0b001, 0b001},
+	'5': {0b111, 0b100, 0b110, 0b001, 0b110},
+	'6': {0b011, 0b100, 0b111, 0b101, 0b111},
+	'7': {0b111, 0b001, 0b010, 0b010, 0b010},
+	'8': {0b111, 0b101, 0b111, 0b101, 0b111},
+	'9': {0b111, 0b101, 0b111, 0b001, 0b110},
+	'_': {0b000, 0b000, 0b000, 0b000, 0b111},
+	'-': {0b000, 0b000, 0b111, 0b000, 0b000},
+	':': {0b000, 0b010, 0b000, 0b010, 0b000},
+	',': {0b000, 0b000, 0b000, 0b010, 0b100},
+	'.': {0b000, 0b000, 0b000, 0b000, 0b010},
+	'(': {0b001, 0b010, 0b010, 0b010, 0b001},
+	')': {0b100, 0b010, 0b010, 0b010, 0b100},
+	'?': {0b110, 0b001, 0b010, 0b000, 0b010},
+	' ': {},
+}
+
+// canvas is an image being drawn by a PNG drawing.
+type canvas struct {
+	*image.RGBA
+}
+
+// WritePNG draws the same picture as WriteSVG as a PNG image, without the need for any
+// renderer. Labels use a small built-in font that draws letters in capitals.
+func WritePNG(w io.Writer, network *Network, routes [][]string, trains []int, size int) error {
+	d := newDrawing(network, routes, trains, size)
+	c := canvas{image.NewRGBA(image.Rect(0, 0, d.width, d.totalHeight()))}
+	c.fillRect(0, 0, d.width, d.totalHeight(), pngBackground)
+
+	for _, conn := range network.Connections {
+		from, okFrom := d.points[conn.From]
+		to, okTo := d.points[conn.To]
+		if !okFrom || !okTo {
+			continue
+		}
+		c.line(from, to, trackWidth, pngTrack)
+		if conn.OneWay {
+			c.arrowHead(from, to, pngTrack)
+		}
+	}
+
+	for routeIdx, route := range routes {
+		colour := parseColor(routeColor(routeIdx))
+		width := max(routeWidth+len(routes)-1-routeIdx, 2)
+		for i := 1; i < len(route); i++ {
+			from, okFrom := d.points[route[i-1]]
+			to, okTo := d.points[route[i]]
+			if okFrom && okTo {
+				c.line(from, to, width, colour)
+			}
+		}
+	}
+
+	for _, station := range network.Stations {
+		point := d.points[station.Name]
+		c.disc(point[0], point[1], stationRadius+1, pngInk)
+		c.disc(point[0], point[1], stationRadius-1, pngBackground)
+		c.text(point[0]+labelOffset, point[1]-labelOffset-5*glyphScale, station.Name)
+	}
+	for routeIdx := range routes {
+		y := d.height + routeIdx*legendLine
+		c.fillRect(drawingMargin, y, drawingMargin+20, y+6, parseColor(routeColor(routeIdx)))
+		c.text(drawingMargin+legendIndent, y-2, d.legend(routeIdx))
+	}
+
+	return png.Encode(w, c.RGBA)
+}
+
+// parseColor converts a "#rrggbb" colour.
+func parseColor(hex string) color.RGBA {
+	value, err := strconv.ParseUint(hex[1:], 16, 32)
+	if err != nil {
+		return pngInk
+	}
+	return color.RGBA{uint8(value >> 16), uint8(value >> 8), uint8(value), 0xff}
+}
+
+func (c canvas) fillRect(x0, y0, x1, y1 int, colour color.RGBA) {
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			c.SetRGBA(x, y, colour)
+		}
+	}
+}
+
+// disc fills a circle of the given radius around x,y.
+func (c canvas) disc(x, y, radius int, colour color.RGBA) {
+	for dy := -radius; dy <= radius; dy++ {
+		for dx := -radius; dx <= radius; dx++ {
+			if dx*dx+dy*dy <= radius*radius {
+				c.SetRGBA(x+dx, y+dy, colour)
+			}
+		}
+	}
+}
+
+// line draws a line of the given width by stamping a disc at every step of Bresenham's algorithm.
+func (c canvas) line(from, to [2]int, width int, colour color.RGBA) {
+	x, y := from[0], from[1]
+	dx, dy := abs(to[0]-x), -abs(to[1]-y)
+	stepX, stepY := 1, 1
+	if to[0] < x {
+		stepX = -1
+	}
+	if to[1] < y {
+		stepY = -1
+	}
+
+	radius := width / 2
+	errSum := dx + dy
+	for {
+		c.disc(x, y, radius, colour)
+		if x == to[0] && y == to[1] {
+			return
+		}
+		if e2 := 2 * errSum; e2 >= dy {
+			errSum += dy
+			x += stepX
+		} else {
+			errSum += dx
+			y += stepY
+		}
+	}
+}
+
+// arrowHead draws an arrow pointing at the station at the end of a one-way connection,
+// just outside its marker.
+func (c canvas) arrowHead(from, to [2]int, colour color.RGBA) {
+	dx, dy := float64(to[0]-from[0]), float64(to[1]-from[1])
+	length := math.Hypot(dx, dy)
+	if length == 0 {
+		return
+	}
+	dx, dy = dx/length, dy/length
+
+	tipDistance := float64(stationRadius + 3)
+	tip := [2]int{to[0] - int(dx*tipDistance), to[1] - int(dy*tipDistance)}
+	for _, side := range []float64{-1, 1} {
+		// The barbs leave the tip backwards at 30 degrees on either side
+		bx := -dx*math.Cos(math.Pi/6) - side*dy*math.Sin(math.Pi/6)
+		by := -dy*math.Cos(math.Pi/6) + side*dx*math.Sin(math.Pi/6)
+		barb := [2]int{tip[0] + int(bx*8), tip[1] + int(by*8)}
+		c.line(tip, barb, trackWidth, colour)
+	}
+}
+
+// text writes a label with its top left corner at x,y.
+func (c canvas) text(x, y int, label string) {
+	for _, r := range label {
+		glyph, ok := glyphs[unicode.ToUpper(r)]
+		if !ok {
+			glyph = glyphs['?']
+		}
+		for row, dots := range glyph {
+			for col := 0; col < 3; col++ {
+				if dots&(0b100>>col) != 0 {
+					c.fillRect(x+col*glyphScale, y+row*glyphScale, x+(col+1)*glyphScale, y+(row+1)*glyphScale, pngInk)
+				}
+			}
+		}
+		x += 4 * glyphScale
+	}
+}
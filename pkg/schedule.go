@@ -0,0 +1,276 @@
+package train
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Move is a single train movement made during a turn.
+// A move whose From equals To records a train waiting in place. A move over a connection
+// that takes several turns is recorded on the turn the train arrives.
+type Move struct {
+	Train    string
+	From     string
+	To       string
+	Duration int // Turns the move took, counting the turn of arrival; 0 or 1 for a single turn
+}
+
+// Schedule is the structured result of a planning run.
+// A schedule planned for several demands has no single Start and End; every train
+// then starts and ends where its demand says.
+type Schedule struct {
+	Start        string
+	End          string
+	Demands      []Demand       // Demands planned together, empty for a single start and end
+	Trains       []string       // Train names in dispatch order
+	Routes       [][]string     // Routes used by the trains, each excluding the start station
+	Durations    []int          // Turns a train needs to travel each route, indexed like Routes
+	TrainRoutes  map[string]int // Index into Routes for every train
+	TrainDemands map[string]int // Index into Demands for every train, when there are demands
+	Turns        [][]Move       // Moves made during each turn
+}
+
+// Stats summarises a schedule.
+type Stats struct {
+	TotalTurns     int
+	RoutesUsed     int
+	TrainsPerRoute []int // Number of trains sent along each route, indexed like Schedule.Routes
+}
+
+// trainName returns the display name of the train with the given number.
+func trainName(number int) string {
+	return fmt.Sprintf("T%d", number)
+}
+
+// TrainStart returns the station the train departs from.
+func (s *Schedule) TrainStart(train string) string {
+	if len(s.Demands) == 0 {
+		return s.Start
+	}
+	return s.Demands[s.TrainDemands[train]].From
+}
+
+// TrainEnd returns the station where the train finishes.
+func (s *Schedule) TrainEnd(train string) string {
+	if len(s.Demands) == 0 {
+		return s.End
+	}
+	return s.Demands[s.TrainDemands[train]].To
+}
+
+// Itineraries returns the stations visited by every train, starting with its start station.
+func (s *Schedule) Itineraries() map[string][]string {
+	itineraries := make(map[string][]string, len(s.Trains))
+	for _, train := range s.Trains {
+		itineraries[train] = []string{s.TrainStart(train)}
+	}
+	for _, turn := range s.Turns {
+		for _, move := range turn {
+			if move.From == move.To {
+				continue
+			}
+			itineraries[move.Train] = append(itineraries[move.Train], move.To)
+		}
+	}
+	return itineraries
+}
+
+// StationRoutes returns the routes used by the trains, each starting with the station its trains
+// depart from, so that a route can be drawn on its own.
+func (s *Schedule) StationRoutes() [][]string {
+	routes := make([][]string, len(s.Routes))
+	for routeIdx, route := range s.Routes {
+		start := s.Start
+		for _, train := range s.Trains {
+			if s.TrainRoutes[train] == routeIdx {
+				start = s.TrainStart(train)
+				break
+			}
+		}
+		routes[routeIdx] = append([]string{start}, route...)
+	}
+	return routes
+}
+
+// Positions replays the schedule and returns the station of every train before the first turn
+// and after each turn, so positions[k] holds where the trains are once turn k is over.
+// A train travelling a connection that takes several turns stays at the station it left until it arrives.
+func (s *Schedule) Positions() []map[string]string {
+	positions := make([]map[string]string, 0, len(s.Turns)+1)
+	current := make(map[string]string, len(s.Trains))
+	for _, train := range s.Trains {
+		current[train] = s.TrainStart(train)
+	}
+	positions = append(positions, current)
+
+	for _, turn := range s.Turns {
+		next := make(map[string]string, len(current))
+		for train, station := range current {
+			next[train] = station
+		}
+		for _, move := range turn {
+			next[move.Train] = move.To
+		}
+		positions = append(positions, next)
+		current = next
+	}
+	return positions
+}
+
+// Stats computes the summary statistics of the schedule.
+func (s *Schedule) Stats() Stats {
+	trainsPerRoute := make([]int, len(s.Routes))
+	for _, routeIdx := range s.TrainRoutes {
+		if routeIdx >= 0 && routeIdx < len(trainsPerRoute) {
+			trainsPerRoute[routeIdx]++
+		}
+	}
+
+	routesUsed := 0
+	for _, count := range trainsPerRoute {
+		if count > 0 {
+			routesUsed++
+		}
+	}
+
+	return Stats{
+		TotalTurns:     len(s.Turns),
+		RoutesUsed:     routesUsed,
+		TrainsPerRoute: trainsPerRoute,
+	}
+}
+
+// assignRoutesFromItineraries fills Routes, Durations and TrainRoutes from the moves actually made.
+// It is used for schedulers that do not commit to a route set up front.
+func (s *Schedule) assignRoutesFromItineraries(graph *Graph) {
+	itineraries := s.Itineraries()
+	routeIndex := make(map[string]int)
+	timer := RouteTimer{Graph: graph, Start: s.Start}
+	s.Routes = nil
+	s.Durations = nil
+	s.TrainRoutes = make(map[string]int, len(s.Trains))
+
+	for _, train := range s.Trains {
+		route := itineraries[train][1:]
+		key := strings.Join(route, "\x00")
+		idx, exists := routeIndex[key]
+		if !exists {
+			idx = len(s.Routes)
+			routeIndex[key] = idx
+			s.Routes = append(s.Routes, route)
+			s.Durations = append(s.Durations, timer.Duration(route))
+		}
+		s.TrainRoutes[train] = idx
+	}
+}
+
+// WriteText renders the schedule in the classic format, one line per turn.
+func WriteText(w io.Writer, schedule *Schedule) error {
+	for _, turn := range schedule.Turns {
+		tokens := make([]string, len(turn))
+		for i, move := range turn {
+			tokens[i] = fmt.Sprintf("%s-%s", move.Train, move.To)
+		}
+		if _, err := fmt.Fprintln(w, strings.Join(tokens, " ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scheduleDocument is the JSON form of a schedule written by WriteJSON.
+type scheduleDocument struct {
+	Start      string           `json:"start,omitempty"`
+	End        string           `json:"end,omitempty"`
+	Demands    []demandDocument `json:"demands,omitempty"`
+	TotalTurns int              `json:"total_turns"`
+	Routes     []routeDocument  `json:"routes"`
+	Trains     []trainDocument  `json:"trains"`
+	Turns      []turnDocument   `json:"turns"`
+}
+
+type demandDocument struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Trains int    `json:"trains"`
+}
+
+type routeDocument struct {
+	Index    int      `json:"index"`
+	Stations []string `json:"stations"` // Excluding the start station
+	Length   int      `json:"length"`   // Turns a train needs to travel the route
+	Trains   int      `json:"trains"`
+}
+
+type trainDocument struct {
+	Name      string   `json:"name"`
+	From      string   `json:"from,omitempty"` // Only for schedules with demands
+	To        string   `json:"to,omitempty"`
+	Route     int      `json:"route"` // Index into routes
+	Itinerary []string `json:"itinerary"`
+}
+
+type turnDocument struct {
+	Turn  int            `json:"turn"`
+	Moves []moveDocument `json:"moves"`
+}
+
+type moveDocument struct {
+	Train    string `json:"train"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Wait     bool   `json:"wait,omitempty"`
+	Duration int    `json:"duration,omitempty"` // Only for moves taking several turns
+}
+
+// WriteJSON renders the schedule as an indented JSON document.
+func WriteJSON(w io.Writer, schedule *Schedule) error {
+	stats := schedule.Stats()
+	itineraries := schedule.Itineraries()
+
+	doc := scheduleDocument{
+		Start:      schedule.Start,
+		End:        schedule.End,
+		TotalTurns: stats.TotalTurns,
+		Routes:     []routeDocument{},
+		Trains:     []trainDocument{},
+		Turns:      []turnDocument{},
+	}
+	for _, demand := range schedule.Demands {
+		doc.Demands = append(doc.Demands, demandDocument{From: demand.From, To: demand.To, Trains: demand.Trains})
+	}
+	for i, route := range schedule.Routes {
+		length := len(route) // One turn per connection when the durations are unknown
+		if i < len(schedule.Durations) {
+			length = schedule.Durations[i]
+		}
+		doc.Routes = append(doc.Routes, routeDocument{Index: i, Stations: route, Length: length, Trains: stats.TrainsPerRoute[i]})
+	}
+	for _, train := range schedule.Trains {
+		route, ok := schedule.TrainRoutes[train]
+		if !ok {
+			route = -1
+		}
+		document := trainDocument{Name: train, Route: route, Itinerary: itineraries[train]}
+		if len(schedule.Demands) > 0 {
+			document.From, document.To = schedule.TrainStart(train), schedule.TrainEnd(train)
+		}
+		doc.Trains = append(doc.Trains, document)
+	}
+	for i, turn := range schedule.Turns {
+		moves := make([]moveDocument, len(turn))
+		for j, move := range turn {
+			moves[j] = moveDocument{Train: move.Train, From: move.From, To: move.To, Wait: move.From == move.To}
+			if move.Duration > 1 {
+				moves[j].Duration = move.Duration
+			}
+		}
+		doc.Turns = append(doc.Turns, turnDocument{Turn: i + 1, Moves: moves})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(doc)
+}
@@ -0,0 +1,136 @@
+package train
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Scheduler computes a schedule for a request that Planner.Plan has already validated.
+type Scheduler interface {
+	Schedule(ctx context.Context, network *Network, start, end string, trains int) (*Schedule, error)
+}
+
+// FleetRouter is implemented by schedulers that can choose the routes for trains of different paces.
+// Planner.PlanTimetable uses it when available; otherwise it reuses the routes the scheduler picks
+// for the same number of identical trains.
+type FleetRouter interface {
+	FleetRoutes(ctx context.Context, network *Network, start, end string, paces []int) ([][]string, error)
+}
+
+// FlowThreshold is the default network size, in stations plus connections, above which
+// AutoScheduler switches from the exhaustive optimizer to the flow scheduler.
+const FlowThreshold = 5000
+
+// Schedulers lists the schedulers that can be selected by name.
+var Schedulers = map[string]Scheduler{
+	"auto":       AutoScheduler{},
+	"exhaustive": ExhaustiveScheduler{},
+	"flow":       FlowScheduler{},
+	"greedy":     GreedyScheduler{},
+}
+
+// SchedulerByName returns the scheduler registered under the given name.
+func SchedulerByName(name string) (Scheduler, error) {
+	if scheduler, ok := Schedulers[name]; ok {
+		return scheduler, nil
+	}
+
+	names := make([]string, 0, len(Schedulers))
+	for known := range Schedulers {
+		names = append(names, known)
+	}
+	sort.Strings(names)
+	return nil, &detailedError{ErrUnknownScheduler, fmt.Sprintf("Unknown algorithm: %s (expected one of %s)", name, strings.Join(names, ", "))}
+}
+
+// AutoScheduler uses the exhaustive optimizer for small networks and the flow scheduler for
+// large ones, or for those with too many routes to enumerate, as its Limits decide.
+type AutoScheduler struct {
+	Limits Limits
+}
+
+func (a AutoScheduler) Schedule(ctx context.Context, network *Network, start, end string, trains int) (*Schedule, error) {
+	return a.choose(network).Schedule(ctx, network, start, end, trains)
+}
+
+func (a AutoScheduler) FleetRoutes(ctx context.Context, network *Network, start, end string, paces []int) ([][]string, error) {
+	return a.choose(network).FleetRoutes(ctx, network, start, end, paces)
+}
+
+// fleetScheduler is a scheduler that can also choose routes for trains of different paces.
+type fleetScheduler interface {
+	Scheduler
+	FleetRouter
+}
+
+// choose returns the scheduler for the network.
+func (a AutoScheduler) choose(network *Network) fleetScheduler {
+	if name, _ := a.Limits.Choose(MeasureNetwork(network)); name == "flow" {
+		return FlowScheduler{}
+	}
+	return ExhaustiveScheduler{}
+}
+
+// ExhaustiveScheduler enumerates every route and every combination of station-disjoint
+// routes, then picks the combination on which the simulated trains need the fewest turns.
+type ExhaustiveScheduler struct{}
+
+func (ExhaustiveScheduler) Schedule(ctx context.Context, network *Network, start, end string, trains int) (*Schedule, error) {
+	combinationRoutes, timer, err := routeCombinations(ctx, network, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	bestRoute, _ := FindOptimalRoute(trains, combinationRoutes, timer)
+
+	return PlanTrainMovements(bestRoute, timer, trains, start, end)
+}
+
+func (ExhaustiveScheduler) FleetRoutes(ctx context.Context, network *Network, start, end string, paces []int) ([][]string, error) {
+	combinationRoutes, timer, err := routeCombinations(ctx, network, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return FindOptimalFleetRoute(paces, combinationRoutes, timer), nil
+}
+
+// routeCombinations enumerates every route from start to end, sorted by duration, and the
+// combinations of routes that can be used together.
+func routeCombinations(ctx context.Context, network *Network, start, end string) ([][][]string, RouteTimer, error) {
+	stationConnections := BuildConnectionMap(network.Stations, network.Connections)
+
+	allRoutes, err := FindAllPossibleRoutes(ctx, stationConnections, start, end)
+	if err != nil {
+		return nil, RouteTimer{}, err
+	}
+
+	timer := RouteTimer{Graph: NewGraph(network), Start: start}
+	sortRoutes(allRoutes, timer)
+
+	combinationRoutes, err := FindAllRouteCombinations(ctx, allRoutes, end, timer)
+	if err != nil {
+		return nil, RouteTimer{}, err
+	}
+	return combinationRoutes, timer, nil
+}
+
+// GreedyScheduler moves the trains one turn at a time along the shortest free path.
+// It scales to large networks but does not guarantee the minimum number of turns.
+type GreedyScheduler struct{}
+
+func (GreedyScheduler) Schedule(ctx context.Context, network *Network, start, end string, trains int) (*Schedule, error) {
+	graph := NewGraph(network)
+	turns, err := MoveTrains(ctx, graph, start, end, trains)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule := &Schedule{Start: start, End: end, Turns: turns}
+	for trainIdx := 1; trainIdx <= trains; trainIdx++ {
+		schedule.Trains = append(schedule.Trains, trainName(trainIdx))
+	}
+	schedule.assignRoutesFromItineraries(graph)
+	return schedule, nil
+}
@@ -0,0 +1,323 @@
+package train
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// mapCases are plans on the maps in the tests directory.
+var mapCases = []struct {
+	file       string
+	start, end string
+	trains     int
+}{
+	{"londonNetwork.map", "waterloo", "st_pancras", 2},
+	{"londonNetwork.map", "waterloo", "st_pancras", 5},
+	{"composersNetwork.map", "beethoven", "part", 9},
+	{"distanceNetwork.map", "beginning", "terminus", 20},
+	{"fairylandNetwork.map", "jungle", "desert", 10},
+	{"foodNetwork.map", "bond_square", "space_port", 4},
+	{"numbersNetwork.map", "two", "four", 4},
+	{"numbersNetwork.map", "one", "six", 5},
+	{"numbersNetwork.map", "one", "six", 12},
+	{"sizeNetwork.map", "small", "large", 9},
+	{"sizeNetwork.map", "small", "36", 5},
+}
+
+// sharedPlatformsMap has a slow two-track connection into the end station, reached either
+// directly or through a station with two platforms.
+const sharedPlatformsMap = `stations:
+s0,0,0
+s1,1,0,cap=2
+s2,2,0
+s3,3,0
+
+connections:
+s0-s1,cap=2
+s1-s2
+s2->s3,3,cap=2
+s0->s2,2,cap=2
+`
+
+// slowShortcutMap has a short route through a slow connection and a long route of quick
+// connections, which carries more trains a turn.
+const slowShortcutMap = `stations:
+s0,0,1
+s1,1,0
+s2,2,3,cap=2
+s3,3,9,cap=2
+s4,4,4
+s5,5,9
+s6,6,8,cap=2
+
+connections:
+s0-s1,3,cap=2
+s0->s2
+s0-s3
+s1-s4,2
+s4-s5,2
+s1-s6,2
+s5-s2,2
+`
+
+// weightedMaps are plans on maps where the route times alone mislead the schedulers.
+var weightedMaps = []struct {
+	name, text string
+	start, end string
+}{
+	{"shared-platforms", sharedPlatformsMap, "s0", "s3"},
+	{"slow-shortcut", slowShortcutMap, "s0", "s6"},
+}
+
+// schedulerNames are the schedulers every plan is checked with.
+var schedulerNames = []string{"exhaustive", "flow", "greedy", "auto"}
+
+func loadMap(t *testing.T, file string) *Network {
+	t.Helper()
+	network, err := ParseNetworkMap(filepath.Join("..", "tests", file))
+	if err != nil {
+		t.Fatalf("%s: %v", file, err)
+	}
+	return network
+}
+
+// randomNetwork returns a small connected network with weights, capacities and one-way
+// connections, small enough for the exhaustive scheduler.
+func randomNetwork(seed int64) *Network {
+	rng := rand.New(rand.NewSource(seed))
+	network := &Network{}
+	count := 4 + rng.Intn(5)
+	for i := 0; i < count; i++ {
+		station := Station{Name: fmt.Sprintf("s%d", i), X: i, Y: rng.Intn(10), Capacity: 1}
+		if rng.Intn(4) == 0 {
+			station.Capacity = 2
+		}
+		network.Stations = append(network.Stations, station)
+	}
+
+	connected := make(map[[2]int]bool)
+	connect := func(a, b int) {
+		if a == b || connected[[2]int{a, b}] || connected[[2]int{b, a}] {
+			return
+		}
+		connected[[2]int{a, b}] = true
+		conn := Connection{From: network.Stations[a].Name, To: network.Stations[b].Name, Weight: 1, Capacity: 1}
+		if rng.Intn(3) == 0 {
+			conn.Weight = 2 + rng.Intn(2)
+		}
+		if rng.Intn(4) == 0 {
+			conn.Capacity = 2
+		}
+		conn.OneWay = rng.Intn(5) == 0
+		network.Connections = append(network.Connections, conn)
+	}
+	for i := 1; i < count; i++ {
+		connect(rng.Intn(i), i)
+	}
+	for extra := rng.Intn(count); extra > 0; extra-- {
+		connect(rng.Intn(count), rng.Intn(count))
+	}
+	return network
+}
+
+func parseMap(t *testing.T, text string) *Network {
+	t.Helper()
+	network, err := ParseNetwork(strings.NewReader(text), ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return network
+}
+
+// bruteForceTurns simulates the trains on every combination of routes the stations and
+// connections hold, and returns the fewest turns any of them needs.
+func bruteForceTurns(t *testing.T, network *Network, start, end string, trains int) int {
+	t.Helper()
+	routes, err := FindAllPossibleRoutes(context.Background(), BuildConnectionMap(network.Stations, network.Connections), start, end)
+	if err != nil {
+		t.Fatal(err)
+	}
+	timer := RouteTimer{Graph: NewGraph(network), Start: start}
+	sortRoutes(routes, timer)
+
+	fewest := math.MaxInt
+	var try func(combination [][]string, next int)
+	try = func(combination [][]string, next int) {
+		if next == len(routes) {
+			if len(combination) == 0 {
+				return
+			}
+			if schedule, err := PlanTrainMovements(combination, timer, trains, start, end); err == nil {
+				fewest = min(fewest, len(schedule.Turns))
+			}
+			return
+		}
+		if canAddRoute(routes[next], combination, end, timer) {
+			try(append(slices.Clip(combination), routes[next]), next)
+		}
+		try(combination, next+1)
+	}
+	try(nil, 0)
+	return fewest
+}
+
+// verify checks a schedule from start to end with VerifySchedule and reports its errors.
+func verify(t *testing.T, network *Network, start, end string, trains int, schedule *Schedule) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "schedule.txt")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteText(file, schedule); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	diagnostics, _, err := VerifySchedule(path, network, start, end, trains, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range diagnostics {
+		if d.Severity == SeverityError {
+			t.Errorf("turn %d: %s [%s]", d.Line, d.Message, d.Code)
+		}
+	}
+}
+
+// plan schedules the trains with the named scheduler.
+func plan(t *testing.T, name string, network *Network, start, end string, trains int) (*Schedule, error) {
+	t.Helper()
+	scheduler, err := SchedulerByName(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	planner := &Planner{Scheduler: scheduler}
+	return planner.Plan(context.Background(), network, start, end, trains)
+}
+
+func TestSchedulesOnMaps(t *testing.T) {
+	for _, tc := range mapCases {
+		network := loadMap(t, tc.file)
+		for _, name := range schedulerNames {
+			t.Run(fmt.Sprintf("%s/%s-%s/%d/%s", tc.file, tc.start, tc.end, tc.trains, name), func(t *testing.T) {
+				schedule, err := plan(t, name, network, tc.start, tc.end, tc.trains)
+				if err != nil {
+					t.Fatal(err)
+				}
+				verify(t, network, tc.start, tc.end, tc.trains, schedule)
+			})
+		}
+	}
+}
+
+func TestSchedulesOnRandomMaps(t *testing.T) {
+	for seed := int64(1); seed <= 300; seed++ {
+		network := randomNetwork(seed)
+		start, end := network.Stations[0].Name, network.Stations[len(network.Stations)-1].Name
+		for _, trains := range []int{1, 4, 9} {
+			for _, name := range schedulerNames {
+				t.Run(fmt.Sprintf("seed%d/%d/%s", seed, trains, name), func(t *testing.T) {
+					schedule, err := plan(t, name, network, start, end, trains)
+					if errors.Is(err, ErrNoPath) {
+						t.Skip("no path between the stations")
+					}
+					if err != nil {
+						t.Fatal(err)
+					}
+					verify(t, network, start, end, trains, schedule)
+				})
+			}
+		}
+	}
+}
+
+// TestFlowMatchesExhaustive checks that the flow scheduler needs as few turns as the exhaustive one.
+func TestFlowMatchesExhaustive(t *testing.T) {
+	type planCase struct {
+		name       string
+		network    *Network
+		start, end string
+		trains     int
+	}
+	var cases []planCase
+	for _, tc := range mapCases {
+		cases = append(cases, planCase{fmt.Sprintf("%s/%s-%s/%d", tc.file, tc.start, tc.end, tc.trains), loadMap(t, tc.file), tc.start, tc.end, tc.trains})
+	}
+	for _, tc := range weightedMaps {
+		for _, trains := range []int{1, 2, 3, 6, 12} {
+			cases = append(cases, planCase{fmt.Sprintf("%s/%d", tc.name, trains), parseMap(t, tc.text), tc.start, tc.end, trains})
+		}
+	}
+	for seed := int64(1); seed <= 2000; seed++ {
+		network := randomNetwork(seed)
+		for _, trains := range []int{1, 4, 7, 9, 12, 15} {
+			cases = append(cases, planCase{fmt.Sprintf("seed%d/%d", seed, trains), network, network.Stations[0].Name, network.Stations[len(network.Stations)-1].Name, trains})
+		}
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			exhaustive, err := plan(t, "exhaustive", tc.network, tc.start, tc.end, tc.trains)
+			if errors.Is(err, ErrNoPath) {
+				t.Skip("no path between the stations")
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			flow, err := plan(t, "flow", tc.network, tc.start, tc.end, tc.trains)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(flow.Turns) != len(exhaustive.Turns) {
+				t.Errorf("flow takes %d turns, exhaustive %d", len(flow.Turns), len(exhaustive.Turns))
+			}
+		})
+	}
+}
+
+// TestSchedulersMatchBruteForce checks that the exhaustive and flow schedulers need as few turns
+// as the best combination of routes when every one of them is simulated.
+func TestSchedulersMatchBruteForce(t *testing.T) {
+	type planCase struct {
+		name       string
+		network    *Network
+		start, end string
+	}
+	var cases []planCase
+	for _, tc := range weightedMaps {
+		cases = append(cases, planCase{tc.name, parseMap(t, tc.text), tc.start, tc.end})
+	}
+	for seed := int64(1); seed <= 500; seed++ {
+		network := randomNetwork(seed)
+		cases = append(cases, planCase{fmt.Sprintf("seed%d", seed), network, network.Stations[0].Name, network.Stations[len(network.Stations)-1].Name})
+	}
+
+	for _, tc := range cases {
+		for _, trains := range []int{1, 3, 7, 12} {
+			t.Run(fmt.Sprintf("%s/%d", tc.name, trains), func(t *testing.T) {
+				if _, err := plan(t, "exhaustive", tc.network, tc.start, tc.end, trains); errors.Is(err, ErrNoPath) {
+					t.Skip("no path between the stations")
+				}
+				fewest := bruteForceTurns(t, tc.network, tc.start, tc.end, trains)
+				for _, name := range []string{"exhaustive", "flow"} {
+					schedule, err := plan(t, name, tc.network, tc.start, tc.end, trains)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if len(schedule.Turns) != fewest {
+						t.Errorf("%s takes %d turns, the best combination %d", name, len(schedule.Turns), fewest)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package train
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// States of a train in a Snapshot.
+const (
+	StateStart      = "start"      // Still at its start station, not having left yet
+	StateMoved      = "moved"      // Arrived at its station during the turn
+	StateWaiting    = "waiting"    // Stayed at its station during the turn
+	StateTravelling = "travelling" // On a connection that takes several turns
+	StateFinished   = "finished"   // At its end station
+)
+
+// TrainPosition is where a train is at the end of a turn.
+type TrainPosition struct {
+	Train   string
+	State   string
+	Station string // Station the train is at, empty while it travels
+	From    string // Connection the train travels, while it travels
+	To      string
+}
+
+// Snapshot is the state of every train and station at the end of a turn.
+type Snapshot struct {
+	Turn     int                 // 0 before the first turn
+	Trains   []TrainPosition     // In the order of Schedule.Trains
+	Stations map[string][]string // Trains at every station the schedule uses, in dispatch order
+}
+
+// Timeline replays the schedule and returns a snapshot before the first turn and after every turn.
+// Unlike Turns, which only records moves, every snapshot lists every train, including those
+// waiting at their start station and those still travelling a connection that takes several turns.
+// Stations cover the start and end stations and every station of the routes, occupied or not.
+func (s *Schedule) Timeline() []Snapshot {
+	type journey struct {
+		left, arrived int
+		from, to      string
+	}
+	journeys := make(map[string][]journey, len(s.Trains))
+	moved := make(map[[2]int]bool) // Turn and dispatch index of every train arriving somewhere
+	order := make(map[string]int, len(s.Trains))
+	for i, train := range s.Trains {
+		order[train] = i
+	}
+	for turnIdx, turn := range s.Turns {
+		for _, move := range turn {
+			if move.From == move.To {
+				continue
+			}
+			arrived := turnIdx + 1
+			journeys[move.Train] = append(journeys[move.Train], journey{arrived - max(move.Duration, 1) + 1, arrived, move.From, move.To})
+			moved[[2]int{arrived, order[move.Train]}] = true
+		}
+	}
+
+	stations := make(map[string]bool)
+	for _, train := range s.Trains {
+		stations[s.TrainStart(train)] = true
+		stations[s.TrainEnd(train)] = true
+	}
+	for _, route := range s.Routes {
+		for _, station := range route {
+			stations[station] = true
+		}
+	}
+
+	positions := s.Positions()
+	timeline := make([]Snapshot, len(positions))
+	for turn, stationOf := range positions {
+		snapshot := Snapshot{Turn: turn, Trains: make([]TrainPosition, len(s.Trains)), Stations: make(map[string][]string, len(stations))}
+		for station := range stations {
+			snapshot.Stations[station] = []string{}
+		}
+
+		for i, train := range s.Trains {
+			position := TrainPosition{Train: train, State: StateWaiting, Station: stationOf[train]}
+			started := false
+			for _, j := range journeys[train] {
+				if j.left <= turn && turn < j.arrived {
+					position = TrainPosition{Train: train, State: StateTravelling, From: j.from, To: j.to}
+				}
+				started = started || j.left <= turn
+			}
+
+			switch {
+			case position.State == StateTravelling:
+			case position.Station == s.TrainEnd(train):
+				position.State = StateFinished
+			case moved[[2]int{turn, i}]:
+				position.State = StateMoved
+			case !started:
+				position.State = StateStart
+			}
+			if position.Station != "" {
+				snapshot.Stations[position.Station] = append(snapshot.Stations[position.Station], train)
+			}
+			snapshot.Trains[i] = position
+		}
+		timeline[turn] = snapshot
+	}
+	return timeline
+}
+
+// WriteTimeline renders the schedule turn by turn with the position of every train and the
+// trains on every station at the end of each turn, starting with the state before the first turn.
+func WriteTimeline(w io.Writer, schedule *Schedule) error {
+	var b strings.Builder
+	for _, snapshot := range schedule.Timeline() {
+		fmt.Fprintf(&b, "Turn %d\n", snapshot.Turn)
+		for _, position := range snapshot.Trains {
+			if position.State == StateTravelling {
+				fmt.Fprintf(&b, "  %s: %s->%s (%s)\n", position.Train, position.From, position.To, position.State)
+			} else {
+				fmt.Fprintf(&b, "  %s: %s (%s)\n", position.Train, position.Station, position.State)
+			}
+		}
+
+		stations := make([]string, 0, len(snapshot.Stations))
+		for station := range snapshot.Stations {
+			stations = append(stations, station)
+		}
+		sort.Strings(stations)
+		occupancy := make([]string, len(stations))
+		for i, station := range stations {
+			occupancy[i] = fmt.Sprintf("%s=%d", station, len(snapshot.Stations[station]))
+		}
+		fmt.Fprintf(&b, "  stations: %s\n", strings.Join(occupancy, " "))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,178 @@
+package train
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// TrainSpec describes one train of a timetable: where it runs and the constraints it must keep.
+type TrainSpec struct {
+	Name      string
+	From      string
+	To        string
+	Departure int // Earliest turn the train may leave its start station, 0 for any turn
+	Deadline  int // Latest turn the train must arrive by, 0 for no deadline
+	Priority  int // Trains with a higher priority move first when trains compete
+	Pace      int // Turns the train needs for every turn of travel, 0 meaning 1
+}
+
+// Paces returns how many turns the train needs for every turn of travel.
+func (s TrainSpec) Paces() int {
+	return max(1, s.Pace)
+}
+
+// SpeedClasses are the paces that can be given by name in a trains file.
+// A freight train moves every other turn.
+var SpeedClasses = map[string]int{
+	"passenger": 1,
+	"freight":   2,
+}
+
+// trainSpecDocument is the JSON form of a TrainSpec read by ParseTrainsFile.
+type trainSpecDocument struct {
+	Name     string `json:"name"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Depart   int    `json:"depart"`
+	Deadline int    `json:"deadline"`
+	Priority int    `json:"priority"`
+	Pace     int    `json:"pace"`
+	Class    string `json:"class"`
+}
+
+// ParseTrainsFile reads the trains of a timetable. The file is either a JSON array of trains
+// or a text file with one "from,to" line per train, optionally followed by "name=s", "depart=n",
+// "deadline=n", "priority=n", "pace=n" and "class=s" naming one of the SpeedClasses.
+// Trains without a name are called T1, T2... in file order.
+func ParseTrainsFile(filePath string) ([]TrainSpec, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var specs []TrainSpec
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		specs, err = parseTrainsJSON(trimmed)
+	} else {
+		specs, err = parseTrainsText(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(specs) == 0 {
+		return nil, &detailedError{ErrInvalidTrainCount, fmt.Sprintf("No trains in %s", filePath)}
+	}
+	seen := make(map[string]bool, len(specs))
+	for i := range specs {
+		if specs[i].Name == "" {
+			specs[i].Name = trainName(i + 1)
+		}
+		if seen[specs[i].Name] {
+			return nil, &ErrDuplicateTrain{Name: specs[i].Name}
+		}
+		seen[specs[i].Name] = true
+	}
+	return specs, nil
+}
+
+func parseTrainsJSON(data []byte) ([]TrainSpec, error) {
+	var documents []trainSpecDocument
+	if err := json.Unmarshal(data, &documents); err != nil {
+		return nil, err
+	}
+
+	specs := make([]TrainSpec, 0, len(documents))
+	for i, doc := range documents {
+		spec := TrainSpec{Name: doc.Name, From: doc.From, To: doc.To, Departure: doc.Depart, Deadline: doc.Deadline, Priority: doc.Priority, Pace: doc.Pace}
+		if doc.Class != "" {
+			pace, ok := SpeedClasses[doc.Class]
+			if !ok || doc.Pace != 0 {
+				return nil, &ErrInvalidLine{Reason: "train class", Text: fmt.Sprintf("train %d", i+1), Line: i + 1, Col: 1}
+			}
+			spec.Pace = pace
+		}
+		if spec.From == "" || spec.To == "" || spec.Departure < 0 || spec.Deadline < 0 || spec.Pace < 0 {
+			return nil, &ErrInvalidLine{Reason: "train", Text: fmt.Sprintf("train %d", i+1), Line: i + 1, Col: 1}
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+func parseTrainsText(data []byte) ([]TrainSpec, error) {
+	var specs []TrainSpec
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line, col := cleanLine(scanner.Text())
+		if len(line) == 0 {
+			continue
+		}
+
+		spec, lineErr := parseTrainLine(line, col)
+		if lineErr != nil {
+			lineErr.Line = lineNumber
+			return nil, lineErr
+		}
+		specs = append(specs, spec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return specs, nil
+}
+
+// parseTrainLine parses a "from,to[,option=value...]" line of a trains file.
+func parseTrainLine(line string, col int) (TrainSpec, *ErrInvalidLine) {
+	fields := splitFields(line, col, ",")
+	if len(fields) < 2 || fields[0].text == "" || fields[1].text == "" {
+		return TrainSpec{}, &ErrInvalidLine{Reason: "train format", Text: line, Col: col}
+	}
+
+	spec := TrainSpec{From: fields[0].text, To: fields[1].text}
+	for _, option := range fields[2:] {
+		key, value, ok := strings.Cut(option.text, "=")
+		if !ok {
+			return TrainSpec{}, &ErrInvalidLine{Reason: "train option", Text: line, Col: option.col}
+		}
+		switch key {
+		case "name":
+			if value == "" {
+				return TrainSpec{}, &ErrInvalidLine{Reason: "train name", Text: line, Col: option.col}
+			}
+			spec.Name = value
+			continue
+		case "class":
+			pace, ok := SpeedClasses[value]
+			if !ok {
+				return TrainSpec{}, &ErrInvalidLine{Reason: "train class", Text: line, Col: option.col}
+			}
+			spec.Pace = pace
+			continue
+		}
+
+		number, err := strconv.Atoi(value)
+		switch {
+		case err != nil:
+			return TrainSpec{}, &ErrInvalidLine{Reason: "train " + key, Text: line, Col: option.col}
+		case key == "depart" && number >= 0:
+			spec.Departure = number
+		case key == "deadline" && number >= 1:
+			spec.Deadline = number
+		case key == "priority":
+			spec.Priority = number
+		case key == "pace" && number >= 1:
+			spec.Pace = number
+		default:
+			return TrainSpec{}, &ErrInvalidLine{Reason: "train option", Text: line, Col: option.col}
+		}
+	}
+	return spec, nil
+}
@@ -1,24 +1,26 @@
 package train
 
 import (
+	"cmp"
+	"context"
+	"errors"
 	"fmt"
 	"math"
-	"os"
 	"slices"
 	"sort"
 )
 
 // BuildConnectionMap creates a map where each station is mapped to a slice of stations it is connected to.
-func BuildConnectionMap(stations []Station, connections [][]string) map[string][]string {
+func BuildConnectionMap(stations []Station, connections []Connection) map[string][]string {
 	stationConnections := make(map[string][]string)
 	var connected []string
 
 	for _, stn := range stations {
 		for _, connection := range connections {
-			if connection[0] == stn.Name {
-				connected = append(connected, connection[1])
-			} else if connection[1] == stn.Name {
-				connected = append(connected, connection[0])
+			if connection.From == stn.Name {
+				connected = append(connected, connection.To)
+			} else if connection.To == stn.Name && !connection.OneWay {
+				connected = append(connected, connection.From)
 			}
 		}
 		if connected != nil {
@@ -31,31 +33,41 @@ func BuildConnectionMap(stations []Station, connections [][]string) map[string][
 }
 
 // FindAllRoutes finds all possible routes from the start station to the end station.
-func FindAllPossibleRoutes(connections map[string][]string, startStation, endStation string) ([][]string, error) {
+// The search stops with the error of ctx once ctx is done.
+func FindAllPossibleRoutes(ctx context.Context, connections map[string][]string, startStation, endStation string) ([][]string, error) {
 	var allRoutes [][]string
 
-	var findPaths func(current, destination string, path []string)
-	findPaths = func(current, destination string, path []string) {
+	var findPaths func(current, destination string, path []string) error
+	findPaths = func(current, destination string, path []string) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		path = append(path, current)
 
 		if current == destination {
 			route := make([]string, len(path))
 			copy(route, path)
 			allRoutes = append(allRoutes, route[1:]) // Exclude the start station
-			return
+			return nil
 		}
 
 		for _, neighbor := range connections[current] {
 			if !slices.Contains(path, neighbor) {
-				findPaths(neighbor, destination, path)
-				//fmt.Println(connections[current])
+				if err := findPaths(neighbor, destination, path); err != nil {
+					return err
+				}
 			}
 		}
+		return nil
 	}
 
-	findPaths(startStation, endStation, []string{})
+	if err := findPaths(startStation, endStation, []string{}); err != nil {
+		return nil, err
+	}
 
-	ValidatePathExistence(allRoutes, startStation, endStation)
+	if err := ValidatePathExistence(allRoutes, startStation, endStation); err != nil {
+		return nil, err
+	}
 
 	sort.Slice(allRoutes, func(i, j int) bool {
 		return len(allRoutes[i]) < len(allRoutes[j])
@@ -64,151 +76,207 @@ func FindAllPossibleRoutes(connections map[string][]string, startStation, endSta
 	return allRoutes, nil
 }
 
-// FindAllRouteCombinations generates all possible combinations of non-redundant routes.
-func FindAllRouteCombinations(allRoutes [][]string) [][][]string {
+// FindAllRouteCombinations generates all possible combinations of routes.
+// Routes taking the same times may still block each other differently, so no combination is
+// left out for taking the same times as another. A route may appear several times in a combination as long as its stations and connections
+// hold that many trains at once, so that multi-track connections carry several trains a turn.
+// The search stops with the error of ctx once ctx is done.
+func FindAllRouteCombinations(ctx context.Context, allRoutes [][]string, endStation string, timer RouteTimer) ([][][]string, error) {
 	var routeCombinations [][][]string
 
 	for startIndex := 0; startIndex < len(allRoutes); startIndex++ {
 		currentCombination := [][]string{allRoutes[startIndex]}
-		generateCombinations(allRoutes, currentCombination, len(allRoutes), startIndex+1, &routeCombinations)
+		if err := generateCombinations(ctx, allRoutes, currentCombination, len(allRoutes), startIndex, endStation, timer, &routeCombinations); err != nil {
+			return nil, err
+		}
 	}
 
-	return routeCombinations
+	return routeCombinations, nil
 }
 
-// generateCombinations recursively generates combinations of routes and checks for redundancy.
-func generateCombinations(allRoutes [][]string, currentCombination [][]string, totalRoutes int, currentIndex int, routeCombinations *[][][]string) {
+// generateCombinations recursively generates combinations of routes.
+// Every complete combination checks ctx, so that a cancelled search returns early.
+func generateCombinations(ctx context.Context, allRoutes [][]string, currentCombination [][]string, totalRoutes int, currentIndex int, endStation string, timer RouteTimer, routeCombinations *[][][]string) error {
 	if currentIndex == totalRoutes {
-		if isUniqueCombination(currentCombination, routeCombinations) {
-			*routeCombinations = append(*routeCombinations, currentCombination)
+		if err := ctx.Err(); err != nil {
+			return err
 		}
-	} else {
-		if canAddRoute(allRoutes[currentIndex], currentCombination) {
-			newCombination := append(currentCombination, allRoutes[currentIndex])
-			generateCombinations(allRoutes, newCombination, totalRoutes, currentIndex+1, routeCombinations)
+		*routeCombinations = append(*routeCombinations, currentCombination)
+		return nil
+	}
+
+	if canAddRoute(allRoutes[currentIndex], currentCombination, endStation, timer) {
+		// Clipped so that the combinations kept by other branches are never overwritten
+		newCombination := append(slices.Clip(currentCombination), allRoutes[currentIndex])
+		// The same route may be added again while its capacity allows
+		if err := generateCombinations(ctx, allRoutes, newCombination, totalRoutes, currentIndex, endStation, timer, routeCombinations); err != nil {
+			return err
 		}
-		generateCombinations(allRoutes, currentCombination, totalRoutes, currentIndex+1, routeCombinations)
 	}
+	return generateCombinations(ctx, allRoutes, currentCombination, totalRoutes, currentIndex+1, endStation, timer, routeCombinations)
 }
 
-// isUniqueCombination checks if a given combination of routes is not redundant compared to existing combinations.
-func isUniqueCombination(newCombination [][]string, existingCombinations *[][][]string) bool {
-	if len(newCombination) == 1 {
-		return isUniqueSingleRoute(newCombination[0], existingCombinations)
+// canAddRoute checks if a route can be added to a combination without using a station or a
+// connection more often than its capacity allows.
+func canAddRoute(newRoute []string, currentCombination [][]string, endStation string, timer RouteTimer) bool {
+	routesThrough := make(map[string]int)
+	routesAlong := make(map[[2]string]int)
+	for _, route := range currentCombination {
+		previous := timer.Start
+		for _, station := range route {
+			routesThrough[station]++
+			routesAlong[timer.Graph.track(previous, station)]++
+			previous = station
+		}
 	}
 
-	newCombinationLengths := getRouteLengths(newCombination)
-	for _, existingCombination := range *existingCombinations {
-		existingCombinationLengths := getRouteLengths(existingCombination)
-		if areCombinationsEquivalent(newCombinationLengths, existingCombinationLengths) {
+	previous := timer.Start
+	for _, station := range newRoute {
+		if station != endStation && routesThrough[station] >= timer.Graph.Capacity(station) {
 			return false
 		}
+		if routesAlong[timer.Graph.track(previous, station)] >= timer.Graph.Tracks(previous, station) {
+			return false
+		}
+		previous = station
 	}
-
 	return true
 }
 
-// isUniqueSingleRoute checks if a single route is unique within existing combinations.
-func isUniqueSingleRoute(route []string, existingCombinations *[][][]string) bool {
-	for _, combination := range *existingCombinations {
-		for _, existingRoute := range combination {
-			if len(route) == len(existingRoute) {
-				return false
-			}
-		}
+// FindOptimalRoute determines the route combination on which the trains arrive soonest when
+// their movements are simulated, as the route times only estimate how long trains wait for
+// each other. Of the combinations needing as few turns, the one with the best estimate is kept.
+// Combinations are simulated from the best estimate on, skipping those that departureBound
+// shows to be slower than the best so far.
+// It returns the combination and the duration of each of its routes.
+func FindOptimalRoute(trainNumber int, routeCombinations [][][]string, timer RouteTimer) (optimalRoute [][]string, optimalRouteInfo []int) {
+	estimates := make([]int, len(routeCombinations))
+	order := make([]int, len(routeCombinations))
+	for i, routes := range routeCombinations {
+		durations, headways := calculateRouteTimes(routes, timer)
+		estimates[i] = calculateTurnsForTrains(durations, headways, trainNumber)
+		order[i] = i
 	}
-	return true
-}
+	sort.SliceStable(order, func(i, j int) bool {
+		return estimates[order[i]] < estimates[order[j]]
+	})
 
-// getRouteLengths returns a slice of lengths for each route in the combination.
-func getRouteLengths(routes [][]string) []int {
-	lengths := make([]int, len(routes))
-	for i, route := range routes {
-		lengths[i] = len(route)
+	best := -1
+	shortestTurns := math.MaxInt
+	for _, i := range order {
+		routes := routeCombinations[i]
+		if departureBound(routes, timer, trainNumber) > shortestTurns {
+			continue
+		}
+		turnsRequired := simulatedTurns(routes, timer, trainNumber)
+		// Ties go to the best estimate, then to the earliest combination
+		if best == -1 || turnsRequired < shortestTurns || (turnsRequired == shortestTurns && (estimates[i] < estimates[best] || (estimates[i] == estimates[best] && i < best))) {
+			best = i
+			shortestTurns = turnsRequired
+		}
 	}
-	return lengths
+	if best == -1 {
+		return nil, nil
+	}
+	optimalRoute = routeCombinations[best]
+	optimalRouteInfo, _ = calculateRouteTimes(optimalRoute, timer)
+	return optimalRoute, optimalRouteInfo
 }
 
-// areCombinationsEquivalent checks if two combinations of routes have the same lengths, after sorting.
-func areCombinationsEquivalent(combinationA, combinationB []int) bool {
-	if len(combinationA) != len(combinationB) {
-		return false
+// departureBound returns a number of turns the trains cannot all arrive in on the routes: trains
+// occupy a track of the connection they leave the start station by for as many turns as it takes,
+// and the last train to leave still needs the duration of the fastest route.
+func departureBound(routes [][]string, timer RouteTimer, trainNumber int) int {
+	fastest := math.MaxInt
+	firstHops := make(map[[2]string]int) // Weight of every connection the routes leave by
+	for _, route := range routes {
+		fastest = min(fastest, timer.Duration(route))
+		firstHops[timer.Graph.track(timer.Start, route[0])] = timer.weight(timer.Start, route[0])
 	}
-	sort.Ints(combinationA)
-	sort.Ints(combinationB)
 
-	for i := range combinationA {
-		if combinationA[i] != combinationB[i] {
-			return false
+	for turn := 1; ; turn++ {
+		departures := 0
+		for track, weight := range firstHops {
+			departures += timer.Graph.Tracks(track[0], track[1]) * ((turn + weight - 1) / weight)
+		}
+		if departures >= trainNumber {
+			return turn + fastest - 1
 		}
 	}
-	return true
 }
 
-// canAddRoute checks if a route can be added to a combination without violating uniqueness.
-func canAddRoute(newRoute []string, currentCombination [][]string) bool {
-	endStation := os.Args[3]
-
-	for _, route := range currentCombination {
-		for _, stationA := range route {
-			for _, stationB := range newRoute {
-				if stationA == endStation || stationB == endStation {
-					continue
-				} else if stationA == stationB {
-					return false
-				}
-			}
-		}
+// simulatedTurns returns the turns the trains need on the routes according to PlanTrainMovements,
+// or math.MaxInt when they block each other.
+func simulatedTurns(routes [][]string, timer RouteTimer, trainNumber int) int {
+	lastRoute := routes[len(routes)-1]
+	schedule, err := PlanTrainMovements(routes, timer, trainNumber, timer.Start, lastRoute[len(lastRoute)-1])
+	if err != nil {
+		return math.MaxInt
 	}
-	return true
+	return len(schedule.Turns)
 }
 
-// FindOptimalRoute determines the best route combination based on the train number and route lengths.
-func FindOptimalRoute(trainNumber int, routeCombinations [][][]string) (optimalRoute [][]string, optimalRouteInfo []int) {
-	routeLengths := calculateRouteLengths(routeCombinations)
+// FindOptimalFleetRoute determines the route combination on which trains with the given paces
+// arrive soonest, according to allocateFleet.
+func FindOptimalFleetRoute(paces []int, routeCombinations [][][]string, timer RouteTimer) [][]string {
+	var optimalRoute [][]string
 	shortestTurns := math.MaxInt
 
-	for index, lengths := range routeLengths {
-		turnsRequired := calculateTurnsForTrains(lengths, trainNumber)
-		if shortestTurns > turnsRequired {
-			optimalRoute = routeCombinations[index]
-			optimalRouteInfo = lengths
+	for _, routes := range routeCombinations {
+		durations, headways := calculateRouteTimes(routes, timer)
+		if _, turnsRequired := allocateFleet(durations, headways, paces, nil); turnsRequired < shortestTurns {
+			optimalRoute = routes
 			shortestTurns = turnsRequired
 		}
 	}
-	return optimalRoute, optimalRouteInfo
+	return optimalRoute
 }
 
-// calculateRouteLengths processes the route combinations and returns a slice of route lengths.
-func calculateRouteLengths(routeCombinations [][][]string) [][]int {
-	var routeLengths [][]int
-
-	for _, routes := range routeCombinations {
-		var lengths []int
-		for _, route := range routes {
-			lengths = append(lengths, len(route))
+// sortRoutes sorts routes by duration, then by stations visited, then by the order the map
+// lists their stations in. Trains are allocated to routes in this order, so route sets holding
+// the same routes are always planned the same way.
+func sortRoutes(routes [][]string, timer RouteTimer) {
+	slices.SortStableFunc(routes, func(a, b []string) int {
+		if c := cmp.Compare(timer.Duration(a), timer.Duration(b)); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(len(a), len(b)); c != 0 {
+			return c
 		}
-		routeLengths = append(routeLengths, lengths)
+		return slices.CompareFunc(a, b, func(x, y string) int {
+			return cmp.Compare(timer.Graph.position(x), timer.Graph.position(y))
+		})
+	})
+}
+
+// calculateRouteTimes returns the duration and the headway of every route. Routes sharing a
+// connection or a station take turns on it, so each of them gets a longer headway.
+func calculateRouteTimes(routes [][]string, timer RouteTimer) (durations, headways []int) {
+	loads := timer.loads(routes)
+	for _, route := range routes {
+		durations = append(durations, timer.Duration(route))
+		headways = append(headways, timer.sharedHeadway(route, loads))
 	}
-	return routeLengths
+	return durations, headways
 }
 
-// calculateTurnsForTrains determines the number of turns required to utilize all trains on the routes.
-func calculateTurnsForTrains(routeLengths []int, trainNumber int) int {
+// calculateTurnsForTrains estimates the number of turns required to utilize all trains on the routes.
+func calculateTurnsForTrains(routeDurations, headways []int, trainNumber int) int {
 	trainCount := 0
 	turns := 0
 
 	for trainCount < trainNumber {
-		trainCount, turns = incrementTurn(trainCount, turns, routeLengths)
+		trainCount, turns = incrementTurn(trainCount, turns, routeDurations, headways)
 	}
 	return turns
 }
 
 // incrementTurn increases the turn count and the number of trains placed on routes for the current turn.
-func incrementTurn(trainCount int, turns int, routeLengths []int) (int, int) {
+// After its first train, a route takes another one every headway turns.
+func incrementTurn(trainCount int, turns int, routeDurations, headways []int) (int, int) {
 	placedTrains := 0
-	for _, length := range routeLengths {
-		if length <= turns {
+	for i, duration := range routeDurations {
+		if duration <= turns && (turns-duration)%headways[i] == 0 {
 			placedTrains++
 		}
 	}
@@ -217,19 +285,150 @@ func incrementTurn(trainCount int, turns int, routeLengths []int) (int, int) {
 	return trainCount, turns + 1
 }
 
-func DisplayTrainMovements(routePlans [][]string, routeDurations []int, numTrains int) {
-	trainAllocation := allocateTrains(routeDurations, numTrains)
-	simulateAndDisplayMovements(trainAllocation, routeDurations, routePlans, numTrains)
+// PlanTrainMovements allocates the trains to the routes and simulates their movements turn by turn.
+// Routes exclude the start station and should be sorted by duration.
+func PlanTrainMovements(routePlans [][]string, timer RouteTimer, numTrains int, startStation, endStation string) (*Schedule, error) {
+	routeDurations, headways := calculateRouteTimes(routePlans, timer)
+	trainAllocation := allocateTrains(routeDurations, headways, numTrains)
+	trainsStatusMap := initializeTrainStatusMap(trainAllocation, routeDurations, numTrains, startStation, endStation)
+
+	schedule := &Schedule{
+		Start:       startStation,
+		End:         endStation,
+		Routes:      routePlans,
+		Durations:   routeDurations,
+		TrainRoutes: make(map[string]int, numTrains),
+	}
+	for trainIdx := 1; trainIdx <= numTrains; trainIdx++ {
+		schedule.Trains = append(schedule.Trains, trainName(trainIdx))
+		schedule.TrainRoutes[trainName(trainIdx)] = trainsStatusMap[trainIdx].pathNumber
+	}
+
+	turns, err := simulate(routePlans, timer, trainsStatusMap)
+	if err != nil {
+		return nil, err
+	}
+	schedule.Turns = turns
+	return schedule, nil
+}
+
+// PlanDemandMovements simulates the trains of several schedules, one per demand, on the same network.
+// Every train keeps the route it was given in its own schedule, but waits for the stations and
+// connections taken by the trains of the other demands. Trains are numbered across the schedules
+// in order, so the trains of earlier demands move first when they compete.
+func PlanDemandMovements(demands []Demand, schedules []*Schedule, graph *Graph) (*Schedule, error) {
+	var trains []combinedTrain
+	demandRoutes := make([][][]string, len(schedules))
+	for demandIdx, schedule := range schedules {
+		demandRoutes[demandIdx] = schedule.Routes
+		for _, name := range schedule.Trains {
+			spec := TrainSpec{Name: trainName(len(trains) + 1)}
+			trains = append(trains, combinedTrain{demand: demandIdx, route: schedule.TrainRoutes[name], spec: spec})
+		}
+	}
+	return planCombinedMovements(demands, demandRoutes, trains, graph)
+}
+
+// combinedTrain places a train on one of the routes of its demand in a combined schedule.
+type combinedTrain struct {
+	demand int // Index into the demands
+	route  int // Index into the routes of the demand
+	spec   TrainSpec
+}
+
+// planCombinedMovements simulates the given trains together on the routes of their demands,
+// keeping their departure turns, paces and priorities, and reports the trains that miss their deadline.
+func planCombinedMovements(demands []Demand, demandRoutes [][][]string, trains []combinedTrain, graph *Graph) (*Schedule, error) {
+	combined := &Schedule{
+		Demands:      demands,
+		TrainRoutes:  make(map[string]int, len(trains)),
+		TrainDemands: make(map[string]int, len(trains)),
+	}
+	routeOffsets := make([]int, len(demandRoutes))
+	for demandIdx, routes := range demandRoutes {
+		routeOffsets[demandIdx] = len(combined.Routes)
+		combined.Routes = append(combined.Routes, routes...)
+		timer := RouteTimer{Graph: graph, Start: demands[demandIdx].From}
+		for _, route := range routes {
+			combined.Durations = append(combined.Durations, timer.Duration(route))
+		}
+	}
+
+	trainsStatusMap := make(map[int]*trainStatus, len(trains))
+	for i, train := range trains {
+		demand := demands[train.demand]
+		route := routeOffsets[train.demand] + train.route
+		combined.Trains = append(combined.Trains, train.spec.Name)
+		combined.TrainRoutes[train.spec.Name] = route
+		combined.TrainDemands[train.spec.Name] = train.demand
+
+		status := newTrainStatus(train.spec.Name, route, demand.From, demand.To)
+		status.departure = train.spec.Departure
+		status.priority = train.spec.Priority
+		status.deadline = train.spec.Deadline
+		status.pace = train.spec.Paces()
+		trainsStatusMap[i+1] = status
+	}
+
+	turns, err := simulate(combined.Routes, RouteTimer{Graph: graph}, trainsStatusMap)
+	if err != nil {
+		return nil, err
+	}
+	combined.Turns = turns
+
+	var missed []MissedDeadline
+	for i := 1; i <= len(trains); i++ {
+		if status := trainsStatusMap[i]; status.deadline > 0 && status.arrival > status.deadline {
+			missed = append(missed, MissedDeadline{Train: status.name, Deadline: status.deadline, Arrival: status.arrival})
+		}
+	}
+	if len(missed) > 0 {
+		return nil, &ErrMissedDeadlines{Missed: missed}
+	}
+	return combined, nil
 }
 
-func allocateTrains(routeDurations []int, numTrains int) map[int][]int {
+// allocateFleet assigns every train, in the given order, to the route where it arrives first.
+// A train with pace p needs p times as long on every connection and keeps its route busy for
+// p headways. Departures may be nil. It returns the route of every train and the turn on which
+// the last train arrives.
+func allocateFleet(routeDurations, headways, paces, departures []int) ([]int, int) {
+	nextFree := make([]int, len(routeDurations)) // First turn each route takes another train
+	for routeIdx := range nextFree {
+		nextFree[routeIdx] = 1
+	}
+
+	assignment := make([]int, len(paces))
+	lastArrival := 0
+	for trainIdx, pace := range paces {
+		earliest := 1
+		if departures != nil {
+			earliest = max(1, departures[trainIdx])
+		}
+
+		bestRoute, bestArrival := -1, math.MaxInt
+		for routeIdx, duration := range routeDurations {
+			arrival := max(earliest, nextFree[routeIdx]) + pace*duration - 1
+			if arrival < bestArrival {
+				bestRoute, bestArrival = routeIdx, arrival
+			}
+		}
+
+		assignment[trainIdx] = bestRoute
+		nextFree[bestRoute] = max(earliest, nextFree[bestRoute]) + pace*headways[bestRoute]
+		lastArrival = max(lastArrival, bestArrival)
+	}
+	return assignment, lastArrival
+}
+
+func allocateTrains(routeDurations, headways []int, numTrains int) map[int][]int {
 	turn := 1
 	trainsAllocated := 0
 	trainAllocation := make(map[int][]int, len(routeDurations))
 
 	for trainsAllocated < numTrains {
 		for routeIdx, duration := range routeDurations {
-			if duration <= turn {
+			if duration <= turn && (turn-duration)%headways[routeIdx] == 0 {
 				trainsAllocated++
 				if trainsAllocated <= numTrains {
 					trainAllocation[routeIdx] = append(trainAllocation[routeIdx], trainsAllocated)
@@ -241,12 +440,6 @@ func allocateTrains(routeDurations []int, numTrains int) map[int][]int {
 	return trainAllocation
 }
 
-func simulateAndDisplayMovements(trainAllocation map[int][]int, routeDurations []int, routePlans [][]string, numTrains int) {
-	stationStatus := initializeStationStatus(routePlans)
-	trainsStatusMap := initializeTrainStatusMap(trainAllocation, routeDurations, routePlans, numTrains)
-	performTrainMovements(stationStatus, trainsStatusMap, routePlans, numTrains)
-}
-
 func initializeStationStatus(routePlans [][]string) map[string]int {
 	stationStatus := make(map[string]int)
 	for _, stations := range routePlans {
@@ -258,21 +451,61 @@ func initializeStationStatus(routePlans [][]string) map[string]int {
 }
 
 type trainStatus struct {
+	name                 string
 	status               string
 	pathNumber           int
-	currentStationNumber int
+	currentStationNumber int    // Index into the route, -1 while the train is at its start station
+	start                string // Station the train departs from
+	end                  string // Station where the train finishes
+	from                 string // Station the train left while travelling
+	left                 int    // Turn the train left from
+	departed             int    // Turn the train left its start station
+	transit              int    // Turns left before a travelling train arrives
+	departure            int    // Earliest turn the train may leave, 0 for any turn
+	deadline             int    // Latest turn the train should arrive by, 0 for none
+	priority             int
+	pace                 int // Turns the train needs for every turn of travel
+	arrival              int // Turn the train arrived on its end station
+}
+
+func newTrainStatus(name string, pathIdx int, start, end string) *trainStatus {
+	return &trainStatus{
+		name:                 name,
+		status:               "starting",
+		pathNumber:           pathIdx,
+		currentStationNumber: -1,
+		start:                start,
+		end:                  end,
+		pace:                 1,
+	}
 }
 
-func initializeTrainStatusMap(trainAllocation map[int][]int, routeDurations []int, routePlans [][]string, numTrains int) map[int]*trainStatus {
+// dispatchOrder returns the order in which trains move within a turn: higher priority first,
+// then the earliest deadline, then the train number.
+func dispatchOrder(trainsStatusMap map[int]*trainStatus) []int {
+	order := make([]int, 0, len(trainsStatusMap))
+	for trainIdx := 1; trainIdx <= len(trainsStatusMap); trainIdx++ {
+		order = append(order, trainIdx)
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := trainsStatusMap[order[i]], trainsStatusMap[order[j]]
+		if a.priority != b.priority {
+			return a.priority > b.priority
+		}
+		if a.deadline != b.deadline {
+			return b.deadline == 0 || (a.deadline != 0 && a.deadline < b.deadline)
+		}
+		return false
+	})
+	return order
+}
+
+func initializeTrainStatusMap(trainAllocation map[int][]int, routeDurations []int, numTrains int, startStation, endStation string) map[int]*trainStatus {
 	trainsStatusMap := make(map[int]*trainStatus)
 
 	for train := 1; train <= numTrains; train++ {
 		pathIdx := findPathForTrain(train, trainAllocation, routeDurations)
-		trainsStatusMap[train] = &trainStatus{
-			status:               "starting",
-			pathNumber:           pathIdx,
-			currentStationNumber: 0,
-		}
+		trainsStatusMap[train] = newTrainStatus(trainName(train), pathIdx, startStation, endStation)
 	}
 	return trainsStatusMap
 }
@@ -295,66 +528,203 @@ func contains(slice []int, item int) bool {
 	return false
 }
 
-func performTrainMovements(stationStatus map[string]int, trainsStatusMap map[int]*trainStatus, routePlans [][]string, numTrains int) {
-	var trainLog string
-	var oneLengthPathUsed bool
-	endStation := os.Args[3]
+// simulation holds the state shared by all trains while their movements are simulated.
+type simulation struct {
+	routePlans     [][]string
+	timer          RouteTimer
+	stationStatus  map[string]int      // Trains occupying a station or about to arrive there
+	trackBusyUntil map[[2]string][]int // Last turn of every train on a connection, by sorted station pair
+	claims         map[[2]string]int   // Trains that have left their start station and have yet to travel each directed hop
+	turnNumber     int
+	progress       int // Departures and turns travelled so far, to notice when every train is stuck
+}
 
-	for trainsStatusMap[numTrains].status != "finished" {
-		for trainIdx := 1; trainIdx <= numTrains; trainIdx++ {
-			if trainStatus, exists := trainsStatusMap[trainIdx]; exists {
-				trainLog, oneLengthPathUsed = processTrainMovement(trainStatus, stationStatus, routePlans, trainIdx, endStation, trainLog, oneLengthPathUsed)
-			}
+// trackFree reports whether the connection has a free track this turn. Trains on a connection
+// block it in both directions, so a single track never carries trains head-on.
+func (sim *simulation) trackFree(track [2]string) bool {
+	busy := sim.trackBusyUntil[track][:0]
+	for _, until := range sim.trackBusyUntil[track] {
+		if until >= sim.turnNumber {
+			busy = append(busy, until)
+		}
+	}
+	sim.trackBusyUntil[track] = busy
+	return len(busy) < sim.timer.Graph.Tracks(track[0], track[1])
+}
+
+// wayClear reports whether no train travels against the route of a train about to leave its
+// start station. Trains meeting head-on would each wait for the other to make way.
+func (sim *simulation) wayClear(trainStatus *trainStatus) bool {
+	previous := trainStatus.start
+	for _, station := range sim.routePlans[trainStatus.pathNumber] {
+		if sim.claims[[2]string{station, previous}] > 0 {
+			return false
+		}
+		previous = station
+	}
+	return true
+}
+
+// claim records that a train leaving its start station is about to travel every hop of its route.
+func (sim *simulation) claim(trainStatus *trainStatus) {
+	previous := trainStatus.start
+	for _, station := range sim.routePlans[trainStatus.pathNumber] {
+		sim.claims[[2]string{previous, station}]++
+		previous = station
+	}
+}
+
+func allFinished(trainsStatusMap map[int]*trainStatus) bool {
+	for _, status := range trainsStatusMap {
+		if status.status != "finished" {
+			return false
+		}
+	}
+	return true
+}
+
+// simulate moves the trains along their routes until every train has finished, in dispatch order.
+// When trains still block each other, the train that left its start station last is held back
+// there for one more turn and the simulation starts over, until the trains get through or the
+// delays stop helping.
+func simulate(routePlans [][]string, timer RouteTimer, trainsStatusMap map[int]*trainStatus) ([][]Move, error) {
+	initial := make(map[int]trainStatus, len(trainsStatusMap))
+	for trainIdx, status := range trainsStatusMap {
+		initial[trainIdx] = *status
+	}
+	order := dispatchOrder(trainsStatusMap)
+
+	maxDelays := -1 // Set by the first deadlock, so that the delays cannot keep pushing it back
+	for delays := 0; ; delays++ {
+		sim := &simulation{
+			routePlans:     routePlans,
+			timer:          timer,
+			stationStatus:  initializeStationStatus(routePlans),
+			trackBusyUntil: make(map[[2]string][]int),
+			claims:         make(map[[2]string]int),
 		}
-		fmt.Println(trainLog)
-		trainLog = ""
-		oneLengthPathUsed = false
-	}
-}
-
-func processTrainMovement(trainStatus *trainStatus, stationStatus map[string]int, routePlans [][]string, trainIdx int, endStation, trainLog string, oneLengthPathUsed bool) (string, bool) {
-	if trainStatus.status == "moving" {
-		nextStation := routePlans[trainStatus.pathNumber][trainStatus.currentStationNumber+1]
-		if stationStatus[nextStation] == 0 {
-			currentStation := routePlans[trainStatus.pathNumber][trainStatus.currentStationNumber]
-			nextStation := routePlans[trainStatus.pathNumber][trainStatus.currentStationNumber+1]
-			stationStatus[currentStation] = 0
-			trainStatus.currentStationNumber++
-			trainLog += fmt.Sprintf("T%d-%v ", trainIdx, nextStation)
-			if nextStation == endStation {
-				trainStatus.status = "finished"
-				stationStatus[nextStation] = 0
-			} else {
-				stationStatus[nextStation] = 1
+		turns, err := performTrainMovements(sim, trainsStatusMap, order)
+		if maxDelays == -1 {
+			maxDelays = len(trainsStatusMap) * sim.turnNumber
+		}
+		if !errors.Is(err, ErrDeadlock) || delays >= maxDelays {
+			return turns, err
+		}
+
+		delayed := -1
+		for _, trainIdx := range order {
+			status := trainsStatusMap[trainIdx]
+			if status.status != "starting" && status.status != "finished" && (delayed == -1 || status.departed >= trainsStatusMap[delayed].departed) {
+				delayed = trainIdx
 			}
 		}
-	} else if trainStatus.status == "starting" {
-		startingStation := routePlans[trainStatus.pathNumber][0]
-		if stationStatus[startingStation] == 0 {
-			if startingStation == endStation {
-				if !oneLengthPathUsed {
-					trainStatus.status = "finished"
-					oneLengthPathUsed = true
-					trainLog += fmt.Sprintf("T%d-%v ", trainIdx, startingStation)
-				}
-				return trainLog, oneLengthPathUsed
-			} else {
-				stationStatus[startingStation] = 1
-				trainStatus.status = "moving"
+		if delayed == -1 {
+			return nil, err
+		}
+		held := initial[delayed]
+		held.departure = trainsStatusMap[delayed].departed + 1
+		initial[delayed] = held
+		for trainIdx, status := range initial {
+			*trainsStatusMap[trainIdx] = status
+		}
+	}
+}
+
+// performTrainMovements runs the simulation until every train has finished, moving the trains
+// of every turn in the given order. It fails with ErrDeadlock when a whole turn passes without
+// any train making progress.
+func performTrainMovements(sim *simulation, trainsStatusMap map[int]*trainStatus, order []int) ([][]Move, error) {
+	var turns [][]Move
+	var turn []Move
+
+	for !allFinished(trainsStatusMap) {
+		sim.turnNumber++
+		progress := sim.progress
+		for _, trainIdx := range order {
+			if trainStatus, exists := trainsStatusMap[trainIdx]; exists {
+				turn = processTrainMovement(sim, trainStatus, turn)
 			}
-			trainLog += fmt.Sprintf("T%d-%v ", trainIdx, startingStation)
 		}
+		if sim.progress == progress {
+			return nil, &detailedError{ErrDeadlock, fmt.Sprintf("Trains block each other from turn %d on", sim.turnNumber)}
+		}
+		turns = append(turns, turn)
+		turn = nil
+	}
+	return turns, nil
+}
+
+// station returns the station at the given index of the train's route, or its start station for -1.
+func (sim *simulation) station(trainStatus *trainStatus, index int) string {
+	if index < 0 {
+		return trainStatus.start
 	}
-	return trainLog, oneLengthPathUsed
+	return sim.routePlans[trainStatus.pathNumber][index]
 }
 
-func ValidateExtraArgs(args []string) {
-	for _, arg := range args {
-		if arg == "extra" || arg == "bonus" {
-			fmt.Println("Handling extra argument:", arg)
+func processTrainMovement(sim *simulation, trainStatus *trainStatus, turn []Move) []Move {
+	switch trainStatus.status {
+	case "travelling":
+		sim.progress++
+		trainStatus.transit--
+		if trainStatus.transit == 0 {
+			turn = arrive(sim, trainStatus, turn)
+		}
+	case "moving", "starting":
+		if sim.turnNumber < trainStatus.departure {
+			sim.progress++ // Waiting for its departure turn is not a deadlock
+			return turn
+		}
+
+		if trainStatus.status == "starting" && !sim.wayClear(trainStatus) {
+			return turn
+		}
+
+		currentStation := sim.station(trainStatus, trainStatus.currentStationNumber)
+		nextStation := sim.station(trainStatus, trainStatus.currentStationNumber+1)
+
+		track := sim.timer.Graph.track(currentStation, nextStation)
+		if (nextStation != trainStatus.end && sim.stationStatus[nextStation] >= sim.timer.Graph.Capacity(nextStation)) || !sim.trackFree(track) {
+			return turn
+		}
+
+		sim.progress++
+		weight := sim.timer.weight(currentStation, nextStation) * trainStatus.pace
+		sim.trackBusyUntil[track] = append(sim.trackBusyUntil[track], sim.turnNumber+weight-1)
+		if trainStatus.status == "moving" {
+			sim.stationStatus[currentStation]--
 		} else {
-			fmt.Fprintf(os.Stderr, "Error: Invalid extra argument: %s\n", arg)
-			os.Exit(1)
+			sim.claim(trainStatus)
+			trainStatus.departed = sim.turnNumber
 		}
+		if nextStation != trainStatus.end {
+			sim.stationStatus[nextStation]++ // Reserved until the train moves on
+		}
+
+		trainStatus.from = currentStation
+		trainStatus.left = sim.turnNumber
+		trainStatus.transit = weight - 1
+		if trainStatus.transit == 0 {
+			turn = arrive(sim, trainStatus, turn)
+		} else {
+			trainStatus.status = "travelling"
+		}
+	}
+	return turn
+}
+
+// arrive moves a train onto the next station of its route and records the move.
+func arrive(sim *simulation, trainStatus *trainStatus, turn []Move) []Move {
+	trainStatus.currentStationNumber++
+	nextStation := sim.station(trainStatus, trainStatus.currentStationNumber)
+
+	turn = append(turn, Move{Train: trainStatus.name, From: trainStatus.from, To: nextStation, Duration: sim.turnNumber - trainStatus.left + 1})
+	sim.claims[[2]string{trainStatus.from, nextStation}]--
+	if nextStation == trainStatus.end {
+		trainStatus.status = "finished"
+		trainStatus.arrival = sim.turnNumber
+	} else {
+		trainStatus.status = "moving"
 	}
+	return turn
 }
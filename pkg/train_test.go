@@ -0,0 +1,70 @@
+package train
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// lineMap is a single-track line on which trains in opposite directions would meet head-on.
+const lineMap = `stations:
+a,0,0
+b,1,0
+c,2,0
+d,3,0
+
+connections:
+a-b
+b-c
+c-d
+`
+
+// checkArrivals checks that every train of the schedule ends on its end station.
+func checkArrivals(t *testing.T, schedule *Schedule) {
+	t.Helper()
+	last := make(map[string]string)
+	for _, turn := range schedule.Turns {
+		for _, move := range turn {
+			last[move.Train] = move.To
+		}
+	}
+	for _, train := range schedule.Trains {
+		if last[train] != schedule.TrainEnd(train) {
+			t.Errorf("%s ends on %q instead of %s", train, last[train], schedule.TrainEnd(train))
+		}
+	}
+}
+
+func TestOppositeTrainsOnLine(t *testing.T) {
+	network, err := ParseNetwork(strings.NewReader(lineMap), ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"exhaustive", "flow"} {
+		scheduler, err := SchedulerByName(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		planner := &Planner{Scheduler: scheduler}
+
+		for _, trains := range []int{1, 3} {
+			t.Run(fmt.Sprintf("%s/demands/%d", name, trains), func(t *testing.T) {
+				schedule, err := planner.PlanDemands(context.Background(), network, []Demand{{"a", "d", trains}, {"d", "a", trains}})
+				if err != nil {
+					t.Fatal(err)
+				}
+				checkArrivals(t, schedule)
+			})
+		}
+
+		t.Run(name+"/timetable", func(t *testing.T) {
+			schedule, err := planner.PlanTimetable(context.Background(), network, []TrainSpec{{Name: "T1", From: "a", To: "d"}, {Name: "T2", From: "d", To: "a"}, {Name: "T3", From: "a", To: "d", Departure: 2}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkArrivals(t, schedule)
+		})
+	}
+}
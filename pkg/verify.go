@@ -0,0 +1,157 @@
+package train
+
+import (
+	"os"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// VerifySchedule replays a schedule file in the classic format, one "T1-station T2-station" line
+// per turn, against the network and reports every rule the schedule breaks instead of stopping at
+// the first one: moves between stations that are not connected, more trains on a station or a
+// connection than it holds, trains moving twice in a turn or faster than a connection allows, and
+// trains that never reach end. A blank line is a turn in which no train arrives anywhere.
+//
+// optimum, when positive, is the number of turns a planned schedule needs; a schedule taking
+// longer gets a warning. VerifySchedule also returns the number of turns of the schedule.
+// The returned error is only set when the file cannot be read.
+func VerifySchedule(filePath string, network *Network, start, end string, trains, optimum int) ([]Diagnostic, int, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, 0, err
+	}
+	var lines []string
+	if text := strings.TrimRight(string(data), " \t\r\n"); text != "" {
+		lines = strings.Split(text, "\n")
+	}
+
+	graph := NewGraph(network)
+	l := &linter{file: filePath}
+
+	// A journey is a train travelling one connection
+	type journey struct {
+		left, arrived int
+		to            string
+	}
+	journeys := make(map[string][]journey, trains)
+	position := make(map[string]string, trains)
+	lastArrival := make(map[string]int, trains)
+	for i := 1; i <= trains; i++ {
+		position[trainName(i)] = start
+	}
+	trackUse := make(map[[2]string]map[int]int)
+
+	for lineIdx, raw := range lines {
+		turn := lineIdx + 1
+		text, col := cleanLine(raw)
+		moved := make(map[string]bool)
+		for _, token := range splitFields(text, col, " ") {
+			if token.text == "" {
+				continue
+			}
+			name, station, ok := strings.Cut(token.text, "-")
+			from, known := position[name]
+			switch {
+			case !ok || name == "" || station == "":
+				l.report(turn, token.col, SeverityError, "bad-move", "Move %q is not in the form TRAIN-STATION", token.text)
+				continue
+			case !known:
+				l.report(turn, token.col, SeverityError, "unknown-train", "Unknown train %s, expected T1 to %s", name, trainName(trains))
+				continue
+			case moved[name]:
+				l.report(turn, token.col, SeverityError, "moved-twice", "%s moves more than once on turn %d", name, turn)
+				continue
+			}
+			moved[name] = true
+
+			switch {
+			case graph.Stations[station].Name == "":
+				l.report(turn, token.col, SeverityError, "unknown-station", "%s moves to unknown station %s", name, station)
+				continue
+			case station == from:
+				continue // The train waits
+			case from == end:
+				l.report(turn, token.col, SeverityError, "finished-train", "%s moves on after arriving at %s", name, end)
+				continue
+			case !slices.Contains(graph.AdjList[from], station):
+				l.report(turn, token.col, SeverityError, "non-adjacent-move", "%s cannot move from %s to %s: there is no connection in that direction", name, from, station)
+				position[name], lastArrival[name] = station, turn
+				continue
+			}
+
+			// The move is recorded on arrival, so the train left as many turns earlier as the connection takes
+			weight := graph.Weight(from, station)
+			left := turn - weight + 1
+			if left <= lastArrival[name] {
+				l.report(turn, token.col, SeverityError, "too-fast", "%s arrives at %s on turn %d, but the connection from %s takes %d turns", name, station, turn, from, weight)
+				left = lastArrival[name] + 1
+			}
+
+			track := graph.track(from, station)
+			if trackUse[track] == nil {
+				trackUse[track] = make(map[int]int)
+			}
+			for t := left; t <= turn; t++ {
+				trackUse[track][t]++
+				if tracks := graph.Tracks(from, station); trackUse[track][t] == tracks+1 {
+					l.report(turn, token.col, SeverityError, "track-busy", "More than %d train(s) use the connection %s-%s on turn %d", tracks, from, station, t)
+				}
+			}
+
+			journeys[name] = append(journeys[name], journey{left, turn, station})
+			position[name], lastArrival[name] = station, turn
+		}
+	}
+	turns := len(lines)
+
+	// A train holds a station from the turn it leaves for it until the turn it leaves it again,
+	// like the simulation reserves it; the start and end stations hold any number of trains
+	occupancy := make(map[int]map[string]int)
+	for _, trainJourneys := range journeys {
+		for k, j := range trainJourneys {
+			if j.to == end || j.to == start {
+				continue
+			}
+			until := turns
+			if k+1 < len(trainJourneys) {
+				until = trainJourneys[k+1].left - 1
+			}
+			for t := j.left; t <= until; t++ {
+				if occupancy[t] == nil {
+					occupancy[t] = make(map[string]int)
+				}
+				occupancy[t][j.to]++
+			}
+		}
+	}
+	for turn := 1; turn <= turns; turn++ {
+		stations := make([]string, 0, len(occupancy[turn]))
+		for station := range occupancy[turn] {
+			stations = append(stations, station)
+		}
+		sort.Strings(stations)
+		for _, station := range stations {
+			if count, capacity := occupancy[turn][station], graph.Capacity(station); count > capacity {
+				l.report(turn, 1, SeverityError, "station-full", "%d trains are on %s on turn %d, which holds %d", count, station, turn, capacity)
+			}
+		}
+	}
+
+	for i := 1; i <= trains; i++ {
+		if name := trainName(i); position[name] != end {
+			l.report(max(turns, 1), 1, SeverityError, "not-finished", "%s ends at %s instead of %s", name, position[name], end)
+		}
+	}
+	if optimum > 0 && turns > optimum {
+		l.report(max(turns, 1), 1, SeverityWarning, "not-optimal", "The schedule takes %d turns where %d are enough", turns, optimum)
+	}
+
+	sort.SliceStable(l.diagnostics, func(i, j int) bool {
+		if l.diagnostics[i].Line != l.diagnostics[j].Line {
+			return l.diagnostics[i].Line < l.diagnostics[j].Line
+		}
+		return l.diagnostics[i].Col < l.diagnostics[j].Col
+	})
+	return l.diagnostics, turns, nil
+}
@@ -0,0 +1,137 @@
+package train
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// verifyMap has a quick route from a to d through b and a slow one through c, which holds two
+// trains on a two-track connection.
+const verifyMap = `stations:
+a,0,0
+b,1,0
+c,1,1,cap=2
+d,2,0
+
+connections:
+a-b
+b-d
+a-c,2,cap=2
+c-d
+`
+
+func TestVerifySchedule(t *testing.T) {
+	network := parseMap(t, verifyMap)
+	cases := []struct {
+		name     string
+		schedule string
+		trains   int
+		optimum  int
+		turns    int
+		want     []string // line:col severity [code] of every diagnostic, in order
+	}{
+		{
+			name:     "one route",
+			schedule: "T1-b\nT1-d T2-b\nT2-d\n",
+			trains:   2,
+			optimum:  3,
+			turns:    3,
+		},
+		{
+			name:     "two routes",
+			schedule: "T1-b\nT1-d T2-c\nT2-d\n",
+			trains:   2,
+			optimum:  3,
+			turns:    3,
+		},
+		{
+			name:     "waiting trains",
+			schedule: "T1-b T2-a\nT2-b T1-d\n\nT2-d",
+			trains:   2,
+			turns:    4,
+		},
+		{
+			name:     "longer than planned",
+			schedule: "T1-b\n\nT1-d\n",
+			trains:   1,
+			optimum:  2,
+			turns:    3,
+			want:     []string{"3:1 warning [not-optimal]"},
+		},
+		{
+			name:     "malformed moves",
+			schedule: "T1b T3-b  T2-z\n  T1-b T1-d\nT1-d",
+			trains:   2,
+			turns:    3,
+			want: []string{
+				"1:1 error [bad-move]",
+				"1:5 error [unknown-train]",
+				"1:11 error [unknown-station]",
+				"2:8 error [moved-twice]",
+				"3:1 error [not-finished]",
+			},
+		},
+		{
+			name:     "impossible moves",
+			schedule: "T1-d T2-c\nT2-d\nT1-b",
+			trains:   2,
+			turns:    3,
+			want: []string{
+				"1:1 error [non-adjacent-move]",
+				"1:6 error [too-fast]",
+				"3:1 error [finished-train]",
+			},
+		},
+		{
+			name:     "crowded",
+			schedule: "T1-b T2-b\nT1-d T2-d\n",
+			trains:   2,
+			turns:    2,
+			want: []string{
+				"1:1 error [station-full]",
+				"1:6 error [track-busy]",
+				"2:6 error [track-busy]",
+			},
+		},
+		{
+			name:     "empty",
+			schedule: "\n",
+			trains:   1,
+			want:     []string{"1:1 error [not-finished]"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "schedule.txt")
+			if err := os.WriteFile(path, []byte(tc.schedule), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			diagnostics, turns, err := VerifySchedule(path, network, "a", "d", tc.trains, tc.optimum)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if turns != tc.turns {
+				t.Errorf("schedule takes %d turns, want %d", turns, tc.turns)
+			}
+			var got []string
+			for _, d := range diagnostics {
+				got = append(got, fmt.Sprintf("%d:%d %s [%s]", d.Line, d.Col, d.Severity, d.Code))
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("got diagnostics\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tc.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestVerifyScheduleMissingFile(t *testing.T) {
+	network := parseMap(t, verifyMap)
+	if _, _, err := VerifySchedule(filepath.Join(t.TempDir(), "missing.txt"), network, "a", "d", 1, 0); !os.IsNotExist(err) {
+		t.Errorf("got error %v, want a missing file", err)
+	}
+}